@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,19 +18,47 @@ import (
 )
 
 var DefaultConfig = Config{
+	Destination:   mqtt.DefaultConfig,
 	HomeAssistant: ha.DefaultConfig,
 	MQTT:          mqtt.DefaultConfig,
+	Source:        mqtt.DefaultConfig,
 	Teslamate:     tm.DefaultConfig,
 	Units:         units.DefaultConfig,
 }
 
 type Config struct {
+	Bridge        bool         `mapstructure:"bridge"`
+	Daemon        bool         `mapstructure:"daemon"`
+	Destination   mqtt.Config  `mapstructure:"destination"`
+	DryRun        bool         `mapstructure:"dry_run"`
 	HomeAssistant ha.Config    `mapstructure:"ha"`
 	MQTT          mqtt.Config  `mapstructure:"mqtt"`
+	NoPrune       bool         `mapstructure:"no_prune"`
+	Purge         PurgeConfig  `mapstructure:"purge"`
+	Render        RenderConfig `mapstructure:"render"`
+	SkipUnchanged bool         `mapstructure:"skip_unchanged"`
+	Source        mqtt.Config  `mapstructure:"source"`
 	Teslamate     tm.Config    `mapstructure:"tm"`
 	Units         units.Config `mapstructure:"units"`
 }
 
+type PurgeConfig struct {
+	Yes bool `mapstructure:"yes"`
+}
+
+type RenderConfig struct {
+	Format      RenderFormat    `mapstructure:"format"`
+	OutputDir   string          `mapstructure:"output_dir"`
+	Vehicle     RenderVehicle   `mapstructure:"vehicle"`
+	VehicleFile string          `mapstructure:"vehicle_file"`
+	Vehicles    []RenderVehicle `mapstructure:"vehicles"`
+}
+
+type RenderVehicle struct {
+	Id         string `mapstructure:"id"`
+	tm.Vehicle `mapstructure:",squash"`
+}
+
 func UnmarshalConfig(config *Config, v *viper.Viper) CobraEFn {
 	return func(cmd *cobra.Command, args []string) error {
 		v.SetConfigName("config")
@@ -48,18 +77,38 @@ func UnmarshalConfig(config *Config, v *viper.Viper) CobraEFn {
 			}
 		}
 
-		if err := v.Unmarshal(&config); err != nil {
-			return err
+		// the source and destination brokers default to the shared mqtt configuration, one key at a time, except
+		// for credentials that conflict with those a broker specifies another way
+		var inherited []string
+		for _, k := range v.AllKeys() {
+			if s, ok := strings.CutPrefix(k, "mqtt."); ok {
+				inherited = append(inherited, s)
+			}
 		}
 
-		if config.MQTT.Username == "" {
-			return fmt.Errorf("mqtt username must be specified")
-		}
+		for _, broker := range []string{"source", "destination"} {
+			var defaults []string
+			for _, s := range inherited {
+				if !ConflictingCredential(v, broker, s) {
+					defaults = append(defaults, s)
+				}
+			}
 
-		if config.MQTT.Password == "" {
-			return fmt.Errorf("mqtt password must be specified")
+			for _, s := range defaults {
+				v.SetDefault(fmt.Sprintf("%s.%s", broker, s), v.Get(fmt.Sprintf("mqtt.%s", s)))
+			}
 		}
 
-		return nil
+		return v.Unmarshal(&config)
 	}
 }
+
+func ConflictingCredential(v *viper.Viper, broker string, key string) bool {
+	return mqtt.ConflictingCredential(key, func(k string) string {
+		k = fmt.Sprintf("%s.%s", broker, k)
+		if !v.IsSet(k) {
+			return ""
+		}
+		return v.GetString(k)
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestUnmarshalConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		args            []string
+		wantSource      func(c mqtt.Config) mqtt.Config
+		wantDestination func(c mqtt.Config) mqtt.Config
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "inherited",
+			file: "mqtt:\n  host: test-host\n  port: 1234\n",
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Host, c.Port = "test-host", 1234
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Host, c.Port = "test-host", 1234
+				return c
+			},
+		},
+		{
+			name: "inherited from flags",
+			args: []string{"--mqtt-host", "test-host"},
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Host = "test-host"
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Host = "test-host"
+				return c
+			},
+		},
+		{
+			name: "overridden per key",
+			file: "mqtt:\n  host: test-host\n  port: 1234\ndestination:\n  port: 4321\n",
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Host, c.Port = "test-host", 1234
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Host, c.Port = "test-host", 4321
+				return c
+			},
+		},
+		{
+			name: "credential given another way",
+			file: "mqtt:\n  username: test-username\n  password: test-password\ndestination:\n  password_file: test-file\n",
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Username, c.Password = "test-username", "test-password"
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Username, c.PasswordFile = "test-username", "test-file"
+				return c
+			},
+		},
+		{
+			name: "anonymous",
+			file: "mqtt:\n  username: test-username\n  password: test-password\nsource:\n  anonymous: true\n",
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Anonymous = true
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Username, c.Password = "test-username", "test-password"
+				return c
+			},
+		},
+		{
+			name: "anonymous inherited",
+			file: "mqtt:\n  anonymous: true\nsource:\n  username: test-username\n",
+			wantSource: func(c mqtt.Config) mqtt.Config {
+				c.Username = "test-username"
+				return c
+			},
+			wantDestination: func(c mqtt.Config) mqtt.Config {
+				c.Anonymous = true
+				return c
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+
+			if tt.file != "" {
+				if err := os.MkdirAll(filepath.Join(dir, "teslamate-discovery"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "teslamate-discovery", "config.yaml"), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd, v := CreateCommand()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			config := DefaultConfig
+			if err := UnmarshalConfig(&config, v)(cmd, nil); err != nil {
+				t.Fatalf("UnmarshalConfig() error = %v", err)
+			}
+
+			// unset lists unmarshal as empty rather than nil
+			base := mqtt.DefaultConfig
+			base.URLs, base.WebSocket.Headers = []string{}, []string{}
+
+			wantSource, wantDestination := base, base
+			if tt.wantSource != nil {
+				wantSource = tt.wantSource(wantSource)
+			}
+			if tt.wantDestination != nil {
+				wantDestination = tt.wantDestination(wantDestination)
+			}
+
+			if !reflect.DeepEqual(config.Source, wantSource) {
+				t.Errorf("UnmarshalConfig() source = %+v, want %+v", config.Source, wantSource)
+			}
+			if !reflect.DeepEqual(config.Destination, wantDestination) {
+				t.Errorf("UnmarshalConfig() destination = %+v, want %+v", config.Destination, wantDestination)
+			}
+		})
+	}
+}
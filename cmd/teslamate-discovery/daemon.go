@@ -0,0 +1,79 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func Daemon(ctx context.Context, source *mqtt.MQTT, destination *mqtt.MQTT, config *Config,
+	vehicles map[string]ha.Device) error {
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+
+	if config.Bridge {
+		g.Go(func() error {
+			return source.Bridge(gctx, destination, config.Teslamate, config.Units)
+		})
+	}
+
+	if !config.Daemon {
+		return Stopped(ctx, g.Wait())
+	}
+
+	g.Go(func() error {
+		return destination.WatchStatus(gctx, config.HomeAssistant, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return Unacknowledged(PublishVehicles(ctx, destination, config, vehicles))
+		})
+	})
+
+	g.Go(func() error {
+		return source.WatchVehicles(gctx, config.Teslamate, vehicles, func(ctx context.Context, id string, dev ha.Device) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err := Unacknowledged(destination.PublishDiscovery(ctx, id, dev, config.HomeAssistant, config.Units)); err != nil {
+				return err
+			}
+
+			vehicles[id] = dev
+			return nil
+		})
+	})
+
+	return Stopped(ctx, g.Wait())
+}
+
+// Unacknowledged reports and discards an UnacknowledgedError, so that a broker that briefly stops acknowledging, such as
+// while reconnecting, does not stop the daemon.  The configuration is published again the next time home assistant
+// restarts.
+func Unacknowledged(err error) error {
+	var unacked mqtt.UnacknowledgedError
+	if !unacked.Collect(err) {
+		return err
+	}
+
+	_ = ReportUnacknowledged(unacked)
+	return nil
+}
+
+// Stopped discards the cancellation of in-flight work when ctx is done, as that is how a daemon is asked to stop.
+func Stopped(ctx context.Context, err error) error {
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return nil
+	}
+
+	return err
+}
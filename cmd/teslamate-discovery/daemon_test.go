@@ -0,0 +1,37 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestUnacknowledged(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "nil"},
+		{
+			name: "unacknowledged",
+			err:  &mqtt.UnacknowledgedError{Messages: []mqtt.Unacknowledged{{Topic: "test-topic", Err: fmt.Errorf("test-error")}}},
+		},
+		{
+			name: "wrapped unacknowledged",
+			err:  fmt.Errorf("test-wrapper: %w", &mqtt.UnacknowledgedError{Messages: []mqtt.Unacknowledged{{Topic: "test-topic", Err: fmt.Errorf("test-error")}}}),
+		},
+		{name: "other", err: fmt.Errorf("test-error"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unacknowledged(tt.err); (err != nil) != tt.wantErr {
+				t.Errorf("Unacknowledged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
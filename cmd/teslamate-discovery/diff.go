@@ -0,0 +1,130 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func CreateDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "diff",
+		Short:        "Compare published Home Assistant MQTT Discovery configuration with what would be published",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+	}
+}
+
+func Diff(config *Config) CobraEFn {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		// a diff is often run beside a daemon with the same configuration, whose availability it must not touch
+		source, destination, err := Connect(ctx, config, "")
+		if err != nil {
+			return err
+		}
+		defer WaitDisconnected(ctx, source, destination)
+
+		vehicles, err := source.ListVehicles(ctx, config.Teslamate)
+		if err != nil {
+			return err
+		}
+
+		if vehicles, err = FilterVehicles(cmd.OutOrStdout(), vehicles, config.Teslamate); err != nil {
+			return err
+		}
+
+		retained, err := destination.ListDiscovery(ctx, config.HomeAssistant, config.Teslamate)
+		if err != nil {
+			return err
+		}
+
+		var existing []mqtt.RetainedConfig
+		for _, r := range retained {
+			if _, ok := vehicles[r.VehicleId]; ok {
+				existing = append(existing, r)
+			}
+		}
+
+		ids := make([]string, 0, len(vehicles))
+		for id := range vehicles {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		var rendered []mqtt.Message
+		for _, id := range ids {
+			r, err := mqtt.DiscoveryMessages(vehicles[id], config.HomeAssistant, config.Units)
+			if err != nil {
+				return err
+			}
+			rendered = append(rendered, r...)
+		}
+
+		d, err := mqtt.Diff(existing, rendered)
+		if err != nil {
+			return err
+		}
+
+		WriteDiff(cmd.OutOrStdout(), d)
+
+		if !d.Empty() {
+			return fmt.Errorf("discovery configuration differs")
+		}
+
+		return nil
+	}
+}
+
+func WriteDiff(w io.Writer, d mqtt.DiscoveryDiff) {
+	for _, m := range d.Added {
+		_, _ = fmt.Fprintf(w, "+ %s\n", m.Topic)
+	}
+
+	for _, m := range d.Removed {
+		_, _ = fmt.Fprintf(w, "- %s\n", m.Topic)
+	}
+
+	for _, m := range d.Changed {
+		_, _ = fmt.Fprintf(w, "~ %s\n", m.Topic)
+
+		if m.Reason != "" {
+			_, _ = fmt.Fprintf(w, "    %s\n", m.Reason)
+		}
+
+		for _, f := range m.Fields {
+			switch f.Op {
+			case mqtt.FieldAdded:
+				_, _ = fmt.Fprintf(w, "    + %s: %s\n", f.Path, FormatDiffValue(f.New))
+			case mqtt.FieldRemoved:
+				_, _ = fmt.Fprintf(w, "    - %s: %s\n", f.Path, FormatDiffValue(f.Old))
+			default:
+				_, _ = fmt.Fprintf(w, "    ~ %s: %s -> %s\n", f.Path, FormatDiffValue(f.Old), FormatDiffValue(f.New))
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+}
+
+func FormatDiffValue(v interface{}) string {
+	b := &strings.Builder{}
+
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestDiff_Availability(t *testing.T) {
+	s := newStubBroker(testVehicle)
+	s.install(t)
+
+	config := newTestConfig()
+	config.Daemon = true
+	config.HomeAssistant.AvailabilityTopic = "test-availability"
+
+	cmd := newTestCommand(context.Background(), CreateDiffCommand())
+	cmd.SetOut(&bytes.Buffer{})
+	_ = Diff(config)(cmd, nil)
+
+	for _, c := range s.configs {
+		if c.AvailabilityTopic != "" {
+			t.Errorf("Diff() availability topic = %q, want none", c.AvailabilityTopic)
+		}
+	}
+	if got := s.topics(); len(got) != 0 {
+		t.Errorf("Diff() published = %v, want nothing", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		published  bool
+		retained   map[string]string
+		wantOutput []string
+		wantErr    bool
+	}{
+		{
+			name:       "unchanged",
+			published:  true,
+			wantOutput: []string{"0 added, 0 removed, 0 changed"},
+		},
+		{
+			name:       "added",
+			wantOutput: []string{"+ homeassistant/sensor/teslamate_cars_1/battery/config", "0 removed, 0 changed"},
+			wantErr:    true,
+		},
+		{
+			name:      "removed",
+			published: true,
+			retained: map[string]string{
+				"homeassistant/sensor/teslamate_cars_1/test-removed/config": `{"name":"test-removed"}`,
+			},
+			wantOutput: []string{"- homeassistant/sensor/teslamate_cars_1/test-removed/config", "0 added, 1 removed, 0 changed"},
+			wantErr:    true,
+		},
+		{
+			name:      "changed",
+			published: true,
+			retained: map[string]string{
+				"homeassistant/sensor/teslamate_cars_1/battery/config": `{"name":"test-name"}`,
+			},
+			wantOutput: []string{"~ homeassistant/sensor/teslamate_cars_1/battery/config", `    ~ name: "test-name" -> "Battery"`, "0 added, 0 removed, 1 changed"},
+			wantErr:    true,
+		},
+		{
+			name: "other vehicle",
+			retained: map[string]string{
+				"homeassistant/sensor/teslamate_cars_2/battery/config": `{"name":"Battery"}`,
+			},
+			wantOutput: []string{"0 removed"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubBroker(testVehicle)
+			s.install(t)
+
+			config := newTestConfig()
+			ctx := context.Background()
+
+			if tt.published {
+				cmd, _ := CreateCommand()
+				if err := Run(config)(newTestCommand(ctx, cmd), nil); err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+			for topic, payload := range tt.retained {
+				s.retained[topic] = []byte(payload)
+			}
+
+			out := &bytes.Buffer{}
+			cmd := newTestCommand(ctx, CreateDiffCommand())
+			cmd.SetOut(out)
+
+			err := Diff(config)(cmd, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, w := range tt.wantOutput {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("Diff() output = %s, want %s", out.String(), w)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	d := mqtt.DiscoveryDiff{
+		Added:   []mqtt.Message{{Topic: "test-added"}},
+		Removed: []mqtt.Message{{Topic: "test-removed"}},
+		Changed: []mqtt.ChangedMessage{
+			{
+				Topic: "test-changed",
+				Fields: []mqtt.FieldDiff{
+					{Op: mqtt.FieldAdded, Path: "a", New: "test-new"},
+					{Op: mqtt.FieldRemoved, Path: "b", Old: 1.0},
+					{Op: mqtt.FieldChanged, Path: "c.d", Old: "<test-old>", New: []interface{}{"test-new"}},
+				},
+			},
+			{Topic: "test-unparseable", Reason: mqtt.UnparseableRetainedPayload},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	WriteDiff(out, d)
+
+	want := `+ test-added
+- test-removed
+~ test-changed
+    + a: "test-new"
+    - b: 1
+    ~ c.d: "<test-old>" -> ["test-new"]
+~ test-unparseable
+    unparseable retained payload
+1 added, 1 removed, 2 changed
+`
+	if out.String() != want {
+		t.Errorf("WriteDiff() = %s, want %s", out.String(), want)
+	}
+}
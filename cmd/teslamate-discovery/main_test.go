@@ -0,0 +1,164 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/tm"
+)
+
+// testVehicle is the retained state of a vehicle complete enough to be discovered.
+var testVehicle = map[string]string{
+	"teslamate/cars/1/display_name": "test-name",
+	"teslamate/cars/1/model":        "3",
+	"teslamate/cars/1/version":      "2024.1.1",
+}
+
+func newTestConfig() *Config {
+	config := DefaultConfig
+	config.Teslamate.IdleTimeout = 10 * time.Millisecond
+	return &config
+}
+
+func newTestCommand(ctx context.Context, cmd *cobra.Command) *cobra.Command {
+	cmd.SetContext(ctx)
+	return cmd
+}
+
+func TestConnect(t *testing.T) {
+	tests := []struct {
+		name              string
+		separate          bool
+		availabilityTopic string
+		want              []string
+	}{
+		{name: "same broker", want: []string{""}},
+		{name: "same broker with availability", availabilityTopic: "test-availability", want: []string{"test-availability"}},
+		{name: "separate brokers", separate: true, want: []string{"", ""}},
+		{name: "separate brokers with availability", separate: true, availabilityTopic: "test-availability", want: []string{"", "test-availability"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubBroker(nil)
+			s.install(t)
+
+			config := newTestConfig()
+			config.HomeAssistant.AvailabilityTopic = "test-ignored"
+			if tt.separate {
+				config.Source.Host = "test-source"
+			}
+
+			if _, _, err := Connect(context.Background(), config, tt.availabilityTopic); err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+
+			var got []string
+			for _, c := range s.configs {
+				got = append(got, c.AvailabilityTopic)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Connect() connections = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Connect() availability topic %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Availability(t *testing.T) {
+	tests := []struct {
+		name   string
+		daemon bool
+		want   string
+	}{
+		{name: "once", want: ""},
+		{name: "daemon", daemon: true, want: "test-availability"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubBroker(testVehicle)
+			s.install(t)
+
+			config := newTestConfig()
+			config.Daemon = tt.daemon
+			config.HomeAssistant.AvailabilityTopic = "test-availability"
+
+			// a daemon runs until it is stopped
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			cmd, _ := CreateCommand()
+			if err := Run(config)(newTestCommand(ctx, cmd), nil); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := s.configs[0].AvailabilityTopic; got != tt.want {
+				t.Errorf("Run() availability topic = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterVehicles(t *testing.T) {
+	vehicles := map[string]ha.Device{
+		"1": {Name: "test-name-1"},
+		"2": {Name: "test-name-2"},
+		"3": {Name: "other-name"},
+	}
+
+	tests := []struct {
+		name       string
+		include    []string
+		exclude    []string
+		want       []string
+		wantOutput string
+		wantErr    bool
+	}{
+		{name: "all", want: []string{"1", "2", "3"}},
+		{name: "included by id", include: []string{"2"}, want: []string{"2"}, wantOutput: `Skipping test-name-1 (1): not matched by vehicles ["2"]`},
+		{name: "included by glob", include: []string{"test-*"}, want: []string{"1", "2"}},
+		{name: "included by regexp", include: []string{"/^other/"}, want: []string{"3"}},
+		{name: "excluded", exclude: []string{"test-name-1"}, want: []string{"2", "3"}, wantOutput: `Skipping test-name-1 (1): matched by exclude vehicles "test-name-1"`},
+		{name: "included and excluded", include: []string{"test-*"}, exclude: []string{"2"}, want: []string{"1"}},
+		{name: "invalid pattern", include: []string{"/[/"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			got, err := FilterVehicles(out, vehicles, tm.Config{Vehicles: tt.include, ExcludeVehicles: tt.exclude})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FilterVehicles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var ids []string
+			for id := range got {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("FilterVehicles() = %v, want %v", ids, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("FilterVehicles() output = %s, want %s", out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func CreatePurgeCommand(viper *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Remove Home Assistant MQTT Discovery configuration for TeslaMate vehicles",
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	_ = flags.BoolP("yes", "y", false, "purge without asking for confirmation")
+	_ = viper.BindPFlag("purge.yes", flags.Lookup("yes"))
+
+	return cmd
+}
+
+func Purge(config *Config) CobraEFn {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		m, err := NewMQTT(ctx, config.Destination)
+		if err != nil {
+			return err
+		}
+		defer WaitDisconnected(ctx, m)
+
+		retained, err := m.ListDiscovery(ctx, config.HomeAssistant, config.Teslamate)
+		if err != nil {
+			return err
+		}
+
+		var topics []string
+		for _, r := range retained {
+			ok, _, err := config.Teslamate.MatchVehicle(r.VehicleId, r.DeviceName())
+			if err != nil {
+				return err
+			}
+			if ok {
+				topics = append(topics, r.Topic)
+			}
+		}
+
+		w := cmd.OutOrStdout()
+
+		if len(topics) == 0 {
+			_, _ = fmt.Fprintln(w, "No Configurations Found")
+			return nil
+		}
+
+		if !config.Purge.Yes {
+			for _, t := range topics {
+				_, _ = fmt.Fprintf(w, "  %s\n", t)
+			}
+
+			_, _ = fmt.Fprintf(w, "Remove %d configurations? [y/N] ", len(topics))
+			s, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(s)); a != "y" && a != "yes" {
+				return fmt.Errorf("purge cancelled")
+			}
+		}
+
+		_, _ = fmt.Fprintln(w, "Purging Configurations")
+		var unacked mqtt.UnacknowledgedError
+		if err := m.Clear(ctx, topics...); !unacked.Collect(err) {
+			return err
+		}
+		if err := ReportUnacknowledged(unacked); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(w, "Purged %d configurations\n", len(topics))
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPurge(t *testing.T) {
+	retained := map[string]string{
+		"homeassistant/sensor/teslamate_cars_1/battery/config": `{"device":{"name":"test-name-1"}}`,
+		"homeassistant/sensor/teslamate_cars_2/battery/config": `{"device":{"name":"test-name-2"}}`,
+		"homeassistant/sensor/other_device/battery/config":     `{"device":{"name":"test-other"}}`,
+	}
+
+	tests := []struct {
+		name       string
+		retained   map[string]string
+		input      string
+		yes        bool
+		include    []string
+		want       []string
+		wantPrompt bool
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name:       "confirmed",
+			retained:   retained,
+			input:      "y\n",
+			want:       []string{"homeassistant/sensor/teslamate_cars_1/battery/config", "homeassistant/sensor/teslamate_cars_2/battery/config"},
+			wantPrompt: true,
+			wantOutput: "Purged 2 configurations",
+		},
+		{
+			name:       "confirmed in full",
+			retained:   retained,
+			input:      " YES \n",
+			want:       []string{"homeassistant/sensor/teslamate_cars_1/battery/config", "homeassistant/sensor/teslamate_cars_2/battery/config"},
+			wantPrompt: true,
+			wantOutput: "Purged 2 configurations",
+		},
+		{
+			name:       "cancelled",
+			retained:   retained,
+			input:      "\n",
+			wantPrompt: true,
+			wantErr:    true,
+		},
+		{
+			name:       "cancelled without input",
+			retained:   retained,
+			wantPrompt: true,
+			wantErr:    true,
+		},
+		{
+			name:       "yes",
+			retained:   retained,
+			yes:        true,
+			want:       []string{"homeassistant/sensor/teslamate_cars_1/battery/config", "homeassistant/sensor/teslamate_cars_2/battery/config"},
+			wantOutput: "Purged 2 configurations",
+		},
+		{
+			name:       "filtered",
+			retained:   retained,
+			yes:        true,
+			include:    []string{"test-name-2"},
+			want:       []string{"homeassistant/sensor/teslamate_cars_2/battery/config"},
+			wantOutput: "Purged 1 configurations",
+		},
+		{
+			name:       "none",
+			wantOutput: "No Configurations Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubBroker(tt.retained)
+			s.install(t)
+
+			config := newTestConfig()
+			config.Purge.Yes = tt.yes
+			config.Teslamate.Vehicles = tt.include
+
+			out := &bytes.Buffer{}
+			cmd := newTestCommand(context.Background(), CreatePurgeCommand(viper.New()))
+			cmd.SetIn(strings.NewReader(tt.input))
+			cmd.SetOut(out)
+
+			err := Purge(config)(cmd, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Purge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := strings.Contains(out.String(), "[y/N]"); got != tt.wantPrompt {
+				t.Errorf("Purge() prompted = %v, want %v", got, tt.wantPrompt)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("Purge() output = %s, want %s", out.String(), tt.wantOutput)
+			}
+			if got := s.topics(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Purge() cleared = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+const DefaultRenderFormat = JSON
+
+// VehicleIdRegexp matches the ids that teslamate gives vehicles in its topics.
+var VehicleIdRegexp = regexp.MustCompile(`^[\d]+$`)
+
+type RenderFormat string
+
+const (
+	JSON      RenderFormat = "json"
+	JSONLines RenderFormat = "jsonl"
+	YAML      RenderFormat = "yaml"
+)
+
+func (r *RenderFormat) Set(v string) error {
+	switch v {
+	case "json", "jsonl", "yaml":
+		*r = RenderFormat(v)
+	default:
+		return fmt.Errorf("must be one of json, jsonl, yaml")
+	}
+	return nil
+}
+
+func (r RenderFormat) String() string {
+	return string(r)
+}
+
+func (r RenderFormat) Type() string {
+	return "string"
+}
+
+func RenderFormatCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{string(JSON), string(JSONLines), string(YAML)}, cobra.ShellCompDirectiveDefault
+}
+
+type RenderedMessage struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func CreateRenderCommand(viper *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render Home Assistant MQTT Discovery configuration without connecting to a broker",
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	f := DefaultRenderFormat
+	flags.Var(&f, "format", "output format [\"json\", \"jsonl\", \"yaml\"]")
+	_ = cmd.RegisterFlagCompletionFunc("format", RenderFormatCompletion)
+	_ = viper.BindPFlag("render.format", flags.Lookup("format"))
+	viper.SetDefault("render.format", DefaultRenderFormat)
+
+	_ = flags.String("output-dir", "", "directory to write a file per topic to (default stdout)")
+	_ = viper.BindPFlag("render.output_dir", flags.Lookup("output-dir"))
+
+	_ = flags.String("vehicle-file", "", "yaml or json file containing a list of vehicles")
+	_ = viper.BindPFlag("render.vehicle_file", flags.Lookup("vehicle-file"))
+
+	_ = flags.String("vehicle-id", "", "teslamate id of the vehicle")
+	_ = viper.BindPFlag("render.vehicle.id", flags.Lookup("vehicle-id"))
+
+	_ = flags.String("vehicle-display-name", "", "display name of the vehicle")
+	_ = viper.BindPFlag("render.vehicle.display_name", flags.Lookup("vehicle-display-name"))
+
+	_ = flags.String("vehicle-model", "", "model of the vehicle (e.g. \"3\")")
+	_ = viper.BindPFlag("render.vehicle.model", flags.Lookup("vehicle-model"))
+
+	_ = flags.String("vehicle-trim-badging", "", "trim badging of the vehicle (e.g. \"P74D\")")
+	_ = viper.BindPFlag("render.vehicle.trim_badging", flags.Lookup("vehicle-trim-badging"))
+
+	_ = flags.String("vehicle-version", "", "software version of the vehicle")
+	_ = viper.BindPFlag("render.vehicle.version", flags.Lookup("vehicle-version"))
+
+	return cmd
+}
+
+func Render(config *Config) CobraEFn {
+	return func(cmd *cobra.Command, args []string) error {
+		vehicles := config.Render.Vehicles
+
+		if config.Render.VehicleFile != "" {
+			v, err := ReadRenderVehicles(config.Render.VehicleFile)
+			if err != nil {
+				return err
+			}
+			vehicles = append(vehicles, v...)
+		}
+
+		if config.Render.Vehicle.Id != "" {
+			vehicles = append(vehicles, config.Render.Vehicle)
+		}
+
+		if len(vehicles) == 0 {
+			return fmt.Errorf("no vehicles to render, specify a vehicle id or vehicle file")
+		}
+
+		devices := make(map[string]ha.Device, len(vehicles))
+		for _, v := range vehicles {
+			// the id becomes part of every topic, so one that teslamate could not have published is a mistake
+			if !VehicleIdRegexp.MatchString(v.Id) {
+				return fmt.Errorf("vehicle id %q must be a teslamate car id, such as 1", v.Id)
+			}
+			devices[v.Id] = v.Device(config.Teslamate, v.Id)
+		}
+
+		// skipped vehicles are reported apart from the rendered output, so that it can be redirected as it is
+		devices, err := FilterVehicles(cmd.ErrOrStderr(), devices, config.Teslamate)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(devices))
+		for id := range devices {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		var messages []mqtt.Message
+		for _, id := range ids {
+			m, err := mqtt.DiscoveryMessages(devices[id], config.HomeAssistant, config.Units)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, m...)
+		}
+
+		if config.Render.OutputDir != "" {
+			return WriteRenderedDir(cmd.OutOrStdout(), config.Render.OutputDir, config.Render.Format, messages)
+		}
+
+		return WriteRendered(cmd.OutOrStdout(), config.Render.Format, messages)
+	}
+}
+
+func ReadRenderVehicles(path string) ([]RenderVehicle, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var vehicles []RenderVehicle
+	if err := v.UnmarshalKey("vehicles", &vehicles); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+func WriteRendered(w io.Writer, format RenderFormat, messages []mqtt.Message) error {
+	r := make([]RenderedMessage, len(messages))
+	for i, m := range messages {
+		r[i] = RenderedMessage{Topic: m.Topic, Payload: m.Payload}
+	}
+
+	switch format {
+	case JSONLines:
+		e := json.NewEncoder(w)
+		e.SetEscapeHTML(false)
+		for _, m := range r {
+			if err := e.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return WriteFormatted(w, format, b)
+	}
+}
+
+func WriteRenderedDir(w io.Writer, dir string, format RenderFormat, messages []mqtt.Message) error {
+	ext := ".json"
+	if format == YAML {
+		ext = ".yaml"
+	}
+
+	for _, m := range messages {
+		path := filepath.Join(dir, filepath.FromSlash(m.Topic)) + ext
+		_, _ = fmt.Fprintf(w, "  %s\n", path)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		b := &bytes.Buffer{}
+		if format == JSONLines {
+			b.Write(m.Payload)
+			b.WriteString("\n")
+		} else if err := WriteFormatted(b, format, m.Payload); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func WriteFormatted(w io.Writer, format RenderFormat, b []byte) error {
+	if format == YAML {
+		// decoding into a node rather than a map preserves the order of the keys
+		var n yaml.Node
+		if err := yaml.Unmarshal(b, &n); err != nil {
+			return err
+		}
+		ClearYAMLStyle(&n)
+
+		e := yaml.NewEncoder(w)
+		e.SetIndent(2)
+		if err := e.Encode(&n); err != nil {
+			return err
+		}
+		return e.Close()
+	}
+
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, b, "", "  "); err != nil {
+		return err
+	}
+	out.WriteString("\n")
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+func ClearYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		ClearYAMLStyle(c)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		vehicles []RenderVehicle
+		file     string
+		include  []string
+		exclude  []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "vehicles",
+			vehicles: []RenderVehicle{{Id: "2"}, {Id: "1"}},
+			want:     []string{"1", "2"},
+		},
+		{
+			name:     "included",
+			vehicles: []RenderVehicle{{Id: "1"}, {Id: "2"}},
+			include:  []string{"2"},
+			want:     []string{"2"},
+		},
+		{
+			name:     "excluded",
+			vehicles: []RenderVehicle{{Id: "1"}, {Id: "2"}},
+			exclude:  []string{"1"},
+			want:     []string{"2"},
+		},
+		{
+			name: "file",
+			file: "vehicles:\n  - id: \"1\"\n  - id: \"3\"\n",
+			want: []string{"1", "3"},
+		},
+		{
+			name:    "blank id in file",
+			file:    "vehicles:\n  - id: \"1\"\n  - display_name: test-name\n",
+			wantErr: true,
+		},
+		{
+			name:     "invalid id",
+			vehicles: []RenderVehicle{{Id: "test/id"}},
+			wantErr:  true,
+		},
+		{
+			name:    "no vehicles",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newTestConfig()
+			config.Render.Format = JSONLines
+			config.Render.Vehicles = tt.vehicles
+			config.Teslamate.Vehicles = tt.include
+			config.Teslamate.ExcludeVehicles = tt.exclude
+
+			if tt.file != "" {
+				config.Render.VehicleFile = filepath.Join(t.TempDir(), "vehicles.yaml")
+				if err := os.WriteFile(config.Render.VehicleFile, []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out := &bytes.Buffer{}
+			cmd := newTestCommand(context.Background(), CreateRenderCommand(viper.New()))
+			cmd.SetOut(out)
+			cmd.SetErr(&bytes.Buffer{})
+
+			err := Render(config)(cmd, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := renderedVehicles(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() vehicles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_OutputDir(t *testing.T) {
+	config := newTestConfig()
+	config.Render.Format = JSON
+	config.Render.OutputDir = t.TempDir()
+	config.Render.Vehicles = []RenderVehicle{{Id: "1"}}
+
+	out := &bytes.Buffer{}
+	cmd := newTestCommand(context.Background(), CreateRenderCommand(viper.New()))
+	cmd.SetOut(out)
+
+	if err := Render(config)(cmd, nil); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := filepath.Join(config.Render.OutputDir, "homeassistant", "sensor", "teslamate_cars_1", "battery", "config.json")
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("Render() output = %s, want %s listed", out.String(), want)
+	}
+
+	b, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("Render() file error = %v", err)
+	}
+	if !json.Valid(b) {
+		t.Errorf("Render() file = %s, want json", b)
+	}
+}
+
+// renderedVehicles returns the ids of the vehicles whose configuration is in rendered json lines.
+func renderedVehicles(t *testing.T, out *bytes.Buffer) []string {
+	t.Helper()
+
+	ids := make(map[string]bool)
+	for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m RenderedMessage
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("Render() line %q error = %v", l, err)
+		}
+
+		_, s, _ := strings.Cut(m.Topic, "teslamate_cars_")
+		id, _, _ := strings.Cut(s, "/")
+		ids[id] = true
+	}
+
+	var got []string
+	for id := range ids {
+		got = append(got, id)
+	}
+	sort.Strings(got)
+
+	return got
+}
+
+func TestWriteRendered(t *testing.T) {
+	messages := []mqtt.Message{{Topic: "test-topic", Payload: []byte(`{"b":"1","a":["test-value"]}`)}}
+
+	tests := []struct {
+		name   string
+		format RenderFormat
+		want   string
+	}{
+		{
+			name:   "json",
+			format: JSON,
+			want: `[
+  {
+    "topic": "test-topic",
+    "payload": {
+      "b": "1",
+      "a": [
+        "test-value"
+      ]
+    }
+  }
+]
+`,
+		},
+		{
+			name:   "jsonl",
+			format: JSONLines,
+			want: `{"topic":"test-topic","payload":{"b":"1","a":["test-value"]}}
+`,
+		},
+		{
+			name:   "yaml",
+			format: YAML,
+			want: `- topic: test-topic
+  payload:
+    b: "1"
+    a:
+      - test-value
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := WriteRendered(out, tt.format, messages); err != nil {
+				t.Fatalf("WriteRendered() error = %v", err)
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("WriteRendered() = %s, want %s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRenderedDir(t *testing.T) {
+	messages := []mqtt.Message{{Topic: "test-prefix/test-topic/config", Payload: []byte(`{"b":"1","a":"2"}`)}}
+
+	tests := []struct {
+		name   string
+		format RenderFormat
+		file   string
+		want   string
+	}{
+		{name: "json", format: JSON, file: "config.json", want: "{\n  \"b\": \"1\",\n  \"a\": \"2\"\n}\n"},
+		{name: "jsonl", format: JSONLines, file: "config.json", want: "{\"b\":\"1\",\"a\":\"2\"}\n"},
+		{name: "yaml", format: YAML, file: "config.yaml", want: "b: \"1\"\na: \"2\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			out := &bytes.Buffer{}
+			if err := WriteRenderedDir(out, dir, tt.format, messages); err != nil {
+				t.Fatalf("WriteRenderedDir() error = %v", err)
+			}
+
+			path := filepath.Join(dir, "test-prefix", "test-topic", tt.file)
+			if want := "  " + path + "\n"; out.String() != want {
+				t.Errorf("WriteRenderedDir() output = %s, want %s", out.String(), want)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("WriteRenderedDir() file error = %v", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("WriteRenderedDir() file = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
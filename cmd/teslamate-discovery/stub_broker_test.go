@@ -0,0 +1,159 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+// stubBroker keeps retained messages in memory, delivering those that match a subscription as it is made.
+type stubBroker struct {
+	mu        sync.Mutex
+	configs   []mqtt.Config
+	published []stubMessage
+	retained  map[string][]byte
+}
+
+func newStubBroker(retained map[string]string) *stubBroker {
+	s := &stubBroker{retained: make(map[string][]byte, len(retained))}
+	for t, p := range retained {
+		s.retained[t] = []byte(p)
+	}
+	return s
+}
+
+// install replaces NewMQTT with a connection to the stub for the rest of the test.
+func (s *stubBroker) install(t interface{ Cleanup(func()) }) {
+	n := NewMQTT
+	t.Cleanup(func() { NewMQTT = n })
+
+	NewMQTT = func(ctx context.Context, config mqtt.Config) (*mqtt.MQTT, error) {
+		s.mu.Lock()
+		s.configs = append(s.configs, config)
+		s.mu.Unlock()
+
+		disconnected := make(chan struct{})
+		close(disconnected)
+
+		return &mqtt.MQTT{Client: s, Disconnected: disconnected, QoS: 1}, nil
+	}
+}
+
+func (s *stubBroker) topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var t []string
+	for _, m := range s.published {
+		t = append(t, m.topic)
+	}
+	return t
+}
+
+func (s *stubBroker) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	p, err := mqtt.PayloadBytes(payload)
+	if err != nil {
+		return &stubToken{err: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.published = append(s.published, stubMessage{topic: topic, payload: p, retained: retained})
+	if retained && len(p) == 0 {
+		delete(s.retained, topic)
+	} else if retained {
+		s.retained[topic] = p
+	}
+
+	return &stubToken{}
+}
+
+func (s *stubBroker) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	s.mu.Lock()
+	var matched []string
+	for t := range s.retained {
+		if mqtt.MatchTopic(topic, t) {
+			matched = append(matched, t)
+		}
+	}
+	sort.Strings(matched)
+
+	messages := make([]*stubMessage, len(matched))
+	for i, t := range matched {
+		messages[i] = &stubMessage{topic: t, payload: s.retained[t], retained: true}
+	}
+	s.mu.Unlock()
+
+	for _, m := range messages {
+		callback(nil, m)
+	}
+
+	return &stubToken{}
+}
+
+func (s *stubBroker) Unsubscribe(topics ...string) paho.Token {
+	return &stubToken{}
+}
+
+type stubMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+func (s *stubMessage) Duplicate() bool {
+	return false
+}
+
+func (s *stubMessage) Qos() byte {
+	return 0
+}
+
+func (s *stubMessage) Retained() bool {
+	return s.retained
+}
+
+func (s *stubMessage) Topic() string {
+	return s.topic
+}
+
+func (s *stubMessage) MessageID() uint16 {
+	return 0
+}
+
+func (s *stubMessage) Payload() []byte {
+	return s.payload
+}
+
+func (s *stubMessage) Ack() {}
+
+type stubToken struct {
+	err error
+}
+
+func (s *stubToken) Wait() bool {
+	return true
+}
+
+func (s *stubToken) WaitTimeout(t time.Duration) bool {
+	return true
+}
+
+func (s *stubToken) Error() error {
+	return s.err
+}
+
+func (s *stubToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
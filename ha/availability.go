@@ -0,0 +1,19 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+type Availability struct {
+	PayloadAvailable    string `json:"payload_available,omitempty"`
+	PayloadNotAvailable string `json:"payload_not_available,omitempty"`
+	Topic               string `json:"topic"`
+	ValueTemplate       string `json:"value_template,omitempty"`
+}
+
+type AvailabilityMode string
+
+const (
+	All    AvailabilityMode = "all"
+	Any    AvailabilityMode = "any"
+	Latest AvailabilityMode = "latest"
+)
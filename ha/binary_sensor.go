@@ -4,19 +4,52 @@
 package ha
 
 type BinarySensor struct {
-	Device                 Device                  `json:"device,omitempty"`
+	Availability           []Availability          `json:"availability,omitempty"`
+	AvailabilityMode       AvailabilityMode        `json:"availability_mode,omitempty"`
+	Device                 Device                  `json:"device,omitzero"`
 	DeviceClass            BinarySensorDeviceClass `json:"device_class,omitempty"`
+	EnabledByDefault       *bool                   `json:"enabled_by_default,omitempty"`
+	EntityCategory         EntityCategory          `json:"entity_category,omitempty"`
 	Icon                   string                  `json:"icon,omitempty"`
 	JSONAttributesTemplate string                  `json:"json_attributes_template,omitempty"`
 	JSONAttributesTopic    string                  `json:"json_attributes_topic,omitempty"`
 	Name                   string                  `json:"name,omitempty"`
+	Origin                 Origin                  `json:"origin,omitzero"`
 	PayloadOff             string                  `json:"payload_off,omitempty"`
 	PayloadOn              string                  `json:"payload_on,omitempty"`
+	Platform               string                  `json:"platform,omitempty"`
 	StateTopic             string                  `json:"state_topic"`
 	UniqueId               string                  `json:"unique_id,omitempty"`
 	ValueTemplate          string                  `json:"value_template,omitempty"`
 }
 
+func (b BinarySensor) Common() Common {
+	return Common{
+		Availability:     b.Availability,
+		AvailabilityMode: b.AvailabilityMode,
+		Device:           b.Device,
+		Origin:           b.Origin,
+		Platform:         b.Platform,
+		StateTopic:       b.StateTopic,
+		UniqueId:         b.UniqueId,
+	}
+}
+
+func (b BinarySensor) Component() string {
+	return "binary_sensor"
+}
+
+func (b BinarySensor) Topics() []string {
+	return []string{b.StateTopic, b.JSONAttributesTopic}
+}
+
+func (b BinarySensor) WithCommon(c Common) Entity {
+	b.Availability, b.AvailabilityMode = c.Availability, c.AvailabilityMode
+	b.Device, b.Origin, b.Platform = c.Device, c.Origin, c.Platform
+	b.StateTopic, b.UniqueId = c.StateTopic, c.UniqueId
+	return b
+}
+
 type BinarySensorDeviceClass string
 
 const (
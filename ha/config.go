@@ -4,13 +4,21 @@
 package ha
 
 const (
+	DefaultDiscoveryMode   = EntityDiscovery
 	DefaultDiscoveryPrefix = "homeassistant"
 )
 
 var DefaultConfig = Config{
+	DiscoveryMode:   DefaultDiscoveryMode,
 	DiscoveryPrefix: DefaultDiscoveryPrefix,
 }
 
 type Config struct {
-	DiscoveryPrefix string `mapstructure:"discovery_prefix"`
+	Abbreviate        bool          `mapstructure:"abbreviate"`
+	AvailabilityTopic string        `mapstructure:"availability_topic"`
+	DiscoveryMode     DiscoveryMode `mapstructure:"discovery_mode"`
+	DiscoveryPrefix   string        `mapstructure:"discovery_prefix"`
+
+	// Origin is set by the application to identify the release that published the configuration
+	Origin Origin `mapstructure:"-"`
 }
@@ -0,0 +1,10 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+type DevicePayload struct {
+	Components map[string]interface{} `json:"components"`
+	Device     Device                 `json:"device"`
+	Origin     Origin                 `json:"origin"`
+}
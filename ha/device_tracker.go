@@ -4,19 +4,52 @@
 package ha
 
 type DeviceTracker struct {
-	Device                 Device                  `json:"device,omitempty"`
+	Availability           []Availability          `json:"availability,omitempty"`
+	AvailabilityMode       AvailabilityMode        `json:"availability_mode,omitempty"`
+	Device                 Device                  `json:"device,omitzero"`
+	EnabledByDefault       *bool                   `json:"enabled_by_default,omitempty"`
+	EntityCategory         EntityCategory          `json:"entity_category,omitempty"`
 	Icon                   string                  `json:"icon,omitempty"`
 	JSONAttributesTemplate string                  `json:"json_attributes_template,omitempty"`
 	JSONAttributesTopic    string                  `json:"json_attributes_topic,omitempty"`
 	Name                   string                  `json:"name,omitempty"`
+	Origin                 Origin                  `json:"origin,omitzero"`
 	PayloadHome            string                  `json:"payload_home,omitempty"`
 	PayloadNotHome         string                  `json:"payload_not_home,omitempty"`
+	Platform               string                  `json:"platform,omitempty"`
 	SourceType             DeviceTrackerSourceType `json:"source_type,omitempty"`
 	StateTopic             string                  `json:"state_topic"`
 	UniqueId               string                  `json:"unique_id,omitempty"`
 	ValueTemplate          string                  `json:"value_template,omitempty"`
 }
 
+func (d DeviceTracker) Common() Common {
+	return Common{
+		Availability:     d.Availability,
+		AvailabilityMode: d.AvailabilityMode,
+		Device:           d.Device,
+		Origin:           d.Origin,
+		Platform:         d.Platform,
+		StateTopic:       d.StateTopic,
+		UniqueId:         d.UniqueId,
+	}
+}
+
+func (d DeviceTracker) Component() string {
+	return "device_tracker"
+}
+
+func (d DeviceTracker) Topics() []string {
+	return []string{d.StateTopic, d.JSONAttributesTopic}
+}
+
+func (d DeviceTracker) WithCommon(c Common) Entity {
+	d.Availability, d.AvailabilityMode = c.Availability, c.AvailabilityMode
+	d.Device, d.Origin, d.Platform = c.Device, c.Origin, c.Platform
+	d.StateTopic, d.UniqueId = c.StateTopic, c.UniqueId
+	return d
+}
+
 type DeviceTrackerSourceType string
 
 const (
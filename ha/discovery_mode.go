@@ -0,0 +1,39 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type DiscoveryMode string
+
+const (
+	DeviceDiscovery DiscoveryMode = "device"
+	EntityDiscovery DiscoveryMode = "entity"
+)
+
+func (d *DiscoveryMode) Set(v string) error {
+	switch v {
+	case "device", "entity":
+		*d = DiscoveryMode(v)
+	default:
+		return fmt.Errorf("must be one of device, entity")
+	}
+	return nil
+}
+
+func (d DiscoveryMode) String() string {
+	return string(d)
+}
+
+func (d DiscoveryMode) Type() string {
+	return "string"
+}
+
+func DiscoveryModeCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{string(DeviceDiscovery), string(EntityDiscovery)}, cobra.ShellCompDirectiveDefault
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+// Entity is implemented by each type of entity, so that the fields they share can be read and replaced without knowing
+// which type it is.
+type Entity interface {
+	// Common returns the fields shared by every type of entity.
+	Common() Common
+
+	// Component returns the platform that the entity belongs to, as it appears in discovery topics.
+	Component() string
+
+	// Topics returns the topics that the entity reads its state from, excluding availability.
+	Topics() []string
+
+	// WithCommon returns a copy of the entity with the fields shared by every type of entity replaced.
+	WithCommon(c Common) Entity
+}
+
+type Common struct {
+	Availability     []Availability
+	AvailabilityMode AvailabilityMode
+	Device           Device
+	Origin           Origin
+	Platform         string
+	StateTopic       string
+	UniqueId         string
+}
@@ -0,0 +1,11 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+type EntityCategory string
+
+const (
+	Configuration EntityCategory = "config"
+	Diagnostic    EntityCategory = "diagnostic"
+)
@@ -0,0 +1,10 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+type Origin struct {
+	Name            string `json:"name"`
+	SoftwareVersion string `json:"sw_version,omitempty"`
+	SupportURL      string `json:"support_url,omitempty"`
+}
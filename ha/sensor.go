@@ -4,12 +4,18 @@
 package ha
 
 type Sensor struct {
-	Device                 Device            `json:"device,omitempty"`
+	Availability           []Availability    `json:"availability,omitempty"`
+	AvailabilityMode       AvailabilityMode  `json:"availability_mode,omitempty"`
+	Device                 Device            `json:"device,omitzero"`
 	DeviceClass            SensorDeviceClass `json:"device_class,omitempty"`
+	EnabledByDefault       *bool             `json:"enabled_by_default,omitempty"`
+	EntityCategory         EntityCategory    `json:"entity_category,omitempty"`
 	Icon                   string            `json:"icon,omitempty"`
 	JSONAttributesTemplate string            `json:"json_attributes_template,omitempty"`
 	JSONAttributesTopic    string            `json:"json_attributes_topic,omitempty"`
 	Name                   string            `json:"name,omitempty"`
+	Origin                 Origin            `json:"origin,omitzero"`
+	Platform               string            `json:"platform,omitempty"`
 	StateClass             StateClass        `json:"state_class,omitempty"`
 	StateTopic             string            `json:"state_topic"`
 	UniqueId               string            `json:"unique_id,omitempty"`
@@ -17,6 +23,33 @@ type Sensor struct {
 	ValueTemplate          string            `json:"value_template,omitempty"`
 }
 
+func (s Sensor) Common() Common {
+	return Common{
+		Availability:     s.Availability,
+		AvailabilityMode: s.AvailabilityMode,
+		Device:           s.Device,
+		Origin:           s.Origin,
+		Platform:         s.Platform,
+		StateTopic:       s.StateTopic,
+		UniqueId:         s.UniqueId,
+	}
+}
+
+func (s Sensor) Component() string {
+	return "sensor"
+}
+
+func (s Sensor) Topics() []string {
+	return []string{s.StateTopic, s.JSONAttributesTopic}
+}
+
+func (s Sensor) WithCommon(c Common) Entity {
+	s.Availability, s.AvailabilityMode = c.Availability, c.AvailabilityMode
+	s.Device, s.Origin, s.Platform = c.Device, c.Origin, c.Platform
+	s.StateTopic, s.UniqueId = c.StateTopic, c.UniqueId
+	return s
+}
+
 type SensorDeviceClass string
 
 const (
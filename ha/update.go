@@ -0,0 +1,57 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package ha
+
+type UpdateEntity struct {
+	Availability          []Availability    `json:"availability,omitempty"`
+	AvailabilityMode      AvailabilityMode  `json:"availability_mode,omitempty"`
+	Device                Device            `json:"device,omitzero"`
+	DeviceClass           UpdateDeviceClass `json:"device_class,omitempty"`
+	EnabledByDefault      *bool             `json:"enabled_by_default,omitempty"`
+	EntityCategory        EntityCategory    `json:"entity_category,omitempty"`
+	Icon                  string            `json:"icon,omitempty"`
+	LatestVersionTemplate string            `json:"latest_version_template,omitempty"`
+	LatestVersionTopic    string            `json:"latest_version_topic,omitempty"`
+	Name                  string            `json:"name,omitempty"`
+	Origin                Origin            `json:"origin,omitzero"`
+	Platform              string            `json:"platform,omitempty"`
+	ReleaseURL            string            `json:"release_url,omitempty"`
+	StateTopic            string            `json:"state_topic"`
+	Title                 string            `json:"title,omitempty"`
+	UniqueId              string            `json:"unique_id,omitempty"`
+	ValueTemplate         string            `json:"value_template,omitempty"`
+}
+
+func (u UpdateEntity) Common() Common {
+	return Common{
+		Availability:     u.Availability,
+		AvailabilityMode: u.AvailabilityMode,
+		Device:           u.Device,
+		Origin:           u.Origin,
+		Platform:         u.Platform,
+		StateTopic:       u.StateTopic,
+		UniqueId:         u.UniqueId,
+	}
+}
+
+func (u UpdateEntity) Component() string {
+	return "update"
+}
+
+func (u UpdateEntity) Topics() []string {
+	return []string{u.StateTopic, u.LatestVersionTopic}
+}
+
+func (u UpdateEntity) WithCommon(c Common) Entity {
+	u.Availability, u.AvailabilityMode = c.Availability, c.AvailabilityMode
+	u.Device, u.Origin, u.Platform = c.Device, c.Origin, c.Platform
+	u.StateTopic, u.UniqueId = c.StateTopic, c.UniqueId
+	return u
+}
+
+type UpdateDeviceClass string
+
+const (
+	Firmware UpdateDeviceClass = "firmware"
+)
@@ -0,0 +1,139 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TopicBase is the key of the base topic that Home Assistant substitutes for a leading ~ in topics.
+const TopicBase = "~"
+
+// Abbreviations are Home Assistant's abbreviations for the keys of a discovery payload, its components, and its
+// availability entries.  Keys without an abbreviation are left as they are.
+var Abbreviations = map[string]string{
+	"availability":             "avty",
+	"availability_mode":        "avty_mode",
+	"components":               "cmps",
+	"device":                   "dev",
+	"device_class":             "dev_cla",
+	"enabled_by_default":       "en",
+	"entity_category":          "ent_cat",
+	"icon":                     "ic",
+	"json_attributes_template": "json_attr_tpl",
+	"json_attributes_topic":    "json_attr_t",
+	"latest_version_template":  "l_ver_tpl",
+	"latest_version_topic":     "l_ver_t",
+	"origin":                   "o",
+	"payload_available":        "pl_avail",
+	"payload_home":             "pl_home",
+	"payload_not_available":    "pl_not_avail",
+	"payload_not_home":         "pl_not_home",
+	"payload_off":              "pl_off",
+	"payload_on":               "pl_on",
+	"platform":                 "p",
+	"release_url":              "rel_u",
+	"source_type":              "src_type",
+	"state_class":              "stat_cla",
+	"state_topic":              "stat_t",
+	"topic":                    "t",
+	"unique_id":                "uniq_id",
+	"unit_of_measurement":      "unit_of_meas",
+	"value_template":           "val_tpl",
+}
+
+var DeviceAbbreviations = map[string]string{
+	"configuration_url": "cu",
+	"connections":       "cns",
+	"hw_version":        "hw",
+	"identifiers":       "ids",
+	"manufacturer":      "mf",
+	"model":             "mdl",
+	"model_id":          "mdl_id",
+	"serial_number":     "sn",
+	"suggested_area":    "sa",
+	"sw_version":        "sw",
+}
+
+var OriginAbbreviations = map[string]string{
+	"support_url": "url",
+	"sw_version":  "sw",
+}
+
+// Abbreviate rewrites a discovery payload with abbreviated keys, and with topics under base shortened to start with ~.
+func Abbreviate(payload []byte, base string) ([]byte, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+
+	a := abbreviateConfig(v, base)
+	a[TopicBase] = base
+
+	return json.Marshal(a)
+}
+
+func abbreviateConfig(config map[string]interface{}, base string) map[string]interface{} {
+	a := make(map[string]interface{}, len(config))
+
+	for k, v := range config {
+		switch k {
+		case "availability":
+			if l, ok := v.([]interface{}); ok {
+				for i, e := range l {
+					if m, ok := e.(map[string]interface{}); ok {
+						l[i] = abbreviateConfig(m, base)
+					}
+				}
+			}
+		case "components":
+			if m, ok := v.(map[string]interface{}); ok {
+				for id, c := range m {
+					if c, ok := c.(map[string]interface{}); ok {
+						m[id] = abbreviateConfig(c, base)
+					}
+				}
+			}
+		case "device":
+			if m, ok := v.(map[string]interface{}); ok {
+				v = abbreviateKeys(m, DeviceAbbreviations)
+			}
+		case "origin":
+			if m, ok := v.(map[string]interface{}); ok {
+				v = abbreviateKeys(m, OriginAbbreviations)
+			}
+		default:
+			if s, ok := v.(string); ok && (k == "topic" || strings.HasSuffix(k, "_topic")) {
+				v = abbreviateTopic(s, base)
+			}
+		}
+
+		a[abbreviateKey(k, Abbreviations)] = v
+	}
+
+	return a
+}
+
+func abbreviateKeys(m map[string]interface{}, abbreviations map[string]string) map[string]interface{} {
+	a := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		a[abbreviateKey(k, abbreviations)] = v
+	}
+	return a
+}
+
+func abbreviateKey(k string, abbreviations map[string]string) string {
+	if a, ok := abbreviations[k]; ok {
+		return a
+	}
+	return k
+}
+
+func abbreviateTopic(topic string, base string) string {
+	if s, ok := strings.CutPrefix(topic, base+"/"); ok {
+		return TopicBase + "/" + s
+	}
+	return topic
+}
@@ -0,0 +1,272 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestAbbreviateMessages(t *testing.T) {
+	d := ha.Device{
+		Identifiers:     []string{"test-prefix/cars/1"},
+		Manufacturer:    "test-manufacturer",
+		Model:           "test-model",
+		Name:            "test-name",
+		SoftwareVersion: "test-sw-version",
+	}
+
+	tests := []struct {
+		name string
+		mode ha.DiscoveryMode
+	}{
+		{name: "entity mode", mode: ha.EntityDiscovery},
+		{name: "device mode", mode: ha.DeviceDiscovery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			haCfg := ha.Config{
+				AvailabilityTopic: "test-availability-topic",
+				DiscoveryMode:     tt.mode,
+				DiscoveryPrefix:   "test-discovery-prefix",
+			}
+
+			full, err := DiscoveryMessages(d, haCfg, units.Config{})
+			if err != nil {
+				t.Fatalf("DiscoveryMessages() error = %v", err)
+			}
+
+			haCfg.Abbreviate = true
+			abbreviated, err := DiscoveryMessages(d, haCfg, units.Config{})
+			if err != nil {
+				t.Fatalf("DiscoveryMessages() error = %v", err)
+			}
+
+			if len(abbreviated) != len(full) {
+				t.Fatalf("DiscoveryMessages() count = %d, want %d", len(abbreviated), len(full))
+			}
+
+			for i := range full {
+				if abbreviated[i].Topic != full[i].Topic {
+					t.Errorf("DiscoveryMessages() topic = %v, want %v", abbreviated[i].Topic, full[i].Topic)
+				}
+				if len(abbreviated[i].Payload) >= len(full[i].Payload) {
+					t.Errorf("DiscoveryMessages() %s payload length = %d, want less than %d", full[i].Topic, len(abbreviated[i].Payload), len(full[i].Payload))
+				}
+
+				var want map[string]interface{}
+				if err := json.Unmarshal(full[i].Payload, &want); err != nil {
+					t.Fatalf("DiscoveryMessages() payload error = %v", err)
+				}
+
+				var got map[string]interface{}
+				if err := json.Unmarshal(abbreviated[i].Payload, &got); err != nil {
+					t.Fatalf("DiscoveryMessages() payload error = %v", err)
+				}
+
+				if got := expand(got, ""); !reflect.DeepEqual(got, want) {
+					t.Errorf("DiscoveryMessages() %s expanded = %v, want %v", full[i].Topic, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAbbreviate(t *testing.T) {
+	got, err := Abbreviate([]byte(`{"device":{"identifiers":["test-base"]},"json_attributes_topic":"test-base/test-attributes","state_topic":"test-base/test-state","availability":[{"topic":"test-other/test-availability"}],"test-key":"test-base/test-value"}`), "test-base")
+	if err != nil {
+		t.Fatalf("Abbreviate() error = %v", err)
+	}
+
+	want := `{"avty":[{"t":"test-other/test-availability"}],"dev":{"ids":["test-base"]},"json_attr_t":"~/test-attributes","stat_t":"~/test-state","test-key":"test-base/test-value","~":"test-base"}`
+	if string(got) != want {
+		t.Errorf("Abbreviate() = %s, want %s", got, want)
+	}
+}
+
+func TestAbbreviate_Keys(t *testing.T) {
+	tests := []struct {
+		name       string
+		parent     string
+		parentWant string
+		want       string
+	}{
+		{name: "availability", want: "avty"},
+		{name: "availability_mode", want: "avty_mode"},
+		{name: "components", want: "cmps"},
+		{name: "device", want: "dev"},
+		{name: "device_class", want: "dev_cla"},
+		{name: "enabled_by_default", want: "en"},
+		{name: "entity_category", want: "ent_cat"},
+		{name: "icon", want: "ic"},
+		{name: "json_attributes_template", want: "json_attr_tpl"},
+		{name: "json_attributes_topic", want: "json_attr_t"},
+		{name: "latest_version_template", want: "l_ver_tpl"},
+		{name: "latest_version_topic", want: "l_ver_t"},
+		{name: "name", want: "name"},
+		{name: "origin", want: "o"},
+		{name: "payload_available", want: "pl_avail"},
+		{name: "payload_home", want: "pl_home"},
+		{name: "payload_not_available", want: "pl_not_avail"},
+		{name: "payload_not_home", want: "pl_not_home"},
+		{name: "payload_off", want: "pl_off"},
+		{name: "payload_on", want: "pl_on"},
+		{name: "platform", want: "p"},
+		{name: "release_url", want: "rel_u"},
+		{name: "source_type", want: "src_type"},
+		{name: "state_class", want: "stat_cla"},
+		{name: "state_topic", want: "stat_t"},
+		{name: "title", want: "title"},
+		{name: "topic", want: "t"},
+		{name: "unique_id", want: "uniq_id"},
+		{name: "unit_of_measurement", want: "unit_of_meas"},
+		{name: "value_template", want: "val_tpl"},
+		{name: "identifiers", parent: "device", parentWant: "dev", want: "ids"},
+		{name: "manufacturer", parent: "device", parentWant: "dev", want: "mf"},
+		{name: "model", parent: "device", parentWant: "dev", want: "mdl"},
+		{name: "name", parent: "device", parentWant: "dev", want: "name"},
+		{name: "suggested_area", parent: "device", parentWant: "dev", want: "sa"},
+		{name: "sw_version", parent: "device", parentWant: "dev", want: "sw"},
+		{name: "name", parent: "origin", parentWant: "o", want: "name"},
+		{name: "support_url", parent: "origin", parentWant: "o", want: "url"},
+		{name: "sw_version", parent: "origin", parentWant: "o", want: "sw"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimPrefix(tt.parent+"/"+tt.name, "/"), func(t *testing.T) {
+			payload, want := map[string]interface{}{tt.name: "test-value"}, map[string]interface{}{tt.want: "test-value"}
+			if tt.parent != "" {
+				payload = map[string]interface{}{tt.parent: payload}
+				want = map[string]interface{}{tt.parentWant: want}
+			}
+
+			b, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			a, err := Abbreviate(b, "test-base")
+			if err != nil {
+				t.Fatalf("Abbreviate() error = %v", err)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(a, &got); err != nil {
+				t.Fatalf("Abbreviate() payload error = %v", err)
+			}
+			delete(got, "~")
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Abbreviate() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// homeAssistantAbbreviations, homeAssistantDeviceAbbreviations, and homeAssistantOriginAbbreviations are the entries of
+// Home Assistant's own tables (homeassistant/components/mqtt/abbreviations.py) for the keys that the catalog publishes,
+// so that a typo in the package's tables is not expanded back to the key it was meant to abbreviate.
+var homeAssistantAbbreviations = map[string]string{
+	"avty":          "availability",
+	"avty_mode":     "availability_mode",
+	"cmps":          "components",
+	"dev":           "device",
+	"dev_cla":       "device_class",
+	"en":            "enabled_by_default",
+	"ent_cat":       "entity_category",
+	"ic":            "icon",
+	"json_attr_tpl": "json_attributes_template",
+	"json_attr_t":   "json_attributes_topic",
+	"l_ver_tpl":     "latest_version_template",
+	"l_ver_t":       "latest_version_topic",
+	"o":             "origin",
+	"pl_avail":      "payload_available",
+	"pl_home":       "payload_home",
+	"pl_not_avail":  "payload_not_available",
+	"pl_not_home":   "payload_not_home",
+	"pl_off":        "payload_off",
+	"pl_on":         "payload_on",
+	"p":             "platform",
+	"rel_u":         "release_url",
+	"src_type":      "source_type",
+	"stat_cla":      "state_class",
+	"stat_t":        "state_topic",
+	"t":             "topic",
+	"uniq_id":       "unique_id",
+	"unit_of_meas":  "unit_of_measurement",
+	"val_tpl":       "value_template",
+}
+
+var homeAssistantDeviceAbbreviations = map[string]string{
+	"ids": "identifiers",
+	"mf":  "manufacturer",
+	"mdl": "model",
+	"sa":  "suggested_area",
+	"sw":  "sw_version",
+}
+
+var homeAssistantOriginAbbreviations = map[string]string{
+	"sw":  "sw_version",
+	"url": "support_url",
+}
+
+// expand reverses abbreviation the way Home Assistant does, expanding keys before replacing the base topic.
+func expand(config map[string]interface{}, base string) map[string]interface{} {
+	if b, ok := config["~"].(string); ok {
+		base = b
+	}
+
+	e := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		if k == "~" {
+			continue
+		}
+
+		k = expandKey(k, homeAssistantAbbreviations)
+		switch k {
+		case "availability":
+			l := v.([]interface{})
+			for i, a := range l {
+				l[i] = expand(a.(map[string]interface{}), base)
+			}
+		case "components":
+			m := v.(map[string]interface{})
+			for id, c := range m {
+				m[id] = expand(c.(map[string]interface{}), base)
+			}
+		case "device":
+			v = expandKeys(v.(map[string]interface{}), homeAssistantDeviceAbbreviations)
+		case "origin":
+			v = expandKeys(v.(map[string]interface{}), homeAssistantOriginAbbreviations)
+		default:
+			if s, ok := v.(string); ok && (k == "topic" || strings.HasSuffix(k, "_topic")) && strings.HasPrefix(s, "~") {
+				v = base + strings.TrimPrefix(s, "~")
+			}
+		}
+
+		e[k] = v
+	}
+
+	return e
+}
+
+func expandKeys(m map[string]interface{}, abbreviations map[string]string) map[string]interface{} {
+	e := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		e[expandKey(k, abbreviations)] = v
+	}
+	return e
+}
+
+func expandKey(k string, abbreviations map[string]string) string {
+	if full, ok := abbreviations[k]; ok {
+		return full
+	}
+	return k
+}
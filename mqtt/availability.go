@@ -0,0 +1,65 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+// Availability binds an entity to TeslaMate's liveness signals for its vehicle, so that Home Assistant shows it as
+// unavailable rather than holding a stale value, and to the application's own availability when it publishes one.
+func Availability(device ha.Device, haCfg ha.Config, entity interface{}) []ha.Availability {
+	var availability []ha.Availability
+
+	// entities that report the vehicle's liveness must stay available to report it
+	var stateTopic string
+	if e, ok := entity.(ha.Entity); ok {
+		stateTopic = e.Common().StateTopic
+	}
+
+	switch stateTopic {
+	case StateTopic(device, "/healthy"), StateTopic(device, "/since"), StateTopic(device, "/state"):
+	default:
+		availability = append(availability,
+			ha.Availability{
+				PayloadAvailable:    "true",
+				PayloadNotAvailable: "false",
+				Topic:               StateTopic(device, "/healthy"),
+			},
+			ha.Availability{
+				Topic:         StateTopic(device, "/state"),
+				ValueTemplate: `{{ "offline" if value == "offline" else "online" }}`,
+			},
+		)
+	}
+
+	if haCfg.AvailabilityTopic != "" {
+		availability = append(availability, ha.Availability{Topic: haCfg.AvailabilityTopic})
+	}
+
+	return availability
+}
+
+func WithAvailability(entity interface{}, availability []ha.Availability) interface{} {
+	e, ok := entity.(ha.Entity)
+	if !ok {
+		return entity
+	}
+
+	c := e.Common()
+	c.Availability, c.AvailabilityMode = availability, ""
+	if len(availability) > 1 {
+		c.AvailabilityMode = ha.All
+	}
+
+	return e.WithCommon(c)
+}
+
+func EntityAvailability(entity interface{}) []ha.Availability {
+	if e, ok := entity.(ha.Entity); ok {
+		return e.Common().Availability
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestAvailability(t *testing.T) {
+	d := ha.Device{Identifiers: []string{"test-prefix/cars/1"}}
+
+	vehicle := []ha.Availability{
+		{PayloadAvailable: "true", PayloadNotAvailable: "false", Topic: "test-prefix/cars/1/healthy"},
+		{Topic: "test-prefix/cars/1/state", ValueTemplate: `{{ "offline" if value == "offline" else "online" }}`},
+	}
+
+	tests := []struct {
+		name   string
+		haCfg  ha.Config
+		entity interface{}
+		want   []ha.Availability
+	}{
+		{
+			name:   "vehicle",
+			entity: ha.Sensor{StateTopic: "test-prefix/cars/1/battery_level"},
+			want:   vehicle,
+		},
+		{
+			name:   "liveness",
+			entity: ha.Sensor{StateTopic: "test-prefix/cars/1/state"},
+		},
+		{
+			name:   "application",
+			haCfg:  ha.Config{AvailabilityTopic: "test-availability-topic"},
+			entity: ha.BinarySensor{StateTopic: "test-prefix/cars/1/healthy"},
+			want:   []ha.Availability{{Topic: "test-availability-topic"}},
+		},
+		{
+			name:   "vehicle and application",
+			haCfg:  ha.Config{AvailabilityTopic: "test-availability-topic"},
+			entity: ha.DeviceTracker{StateTopic: "test-prefix/cars/1/geofence"},
+			want:   append(vehicle, ha.Availability{Topic: "test-availability-topic"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Availability(d, tt.haCfg, tt.entity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Availability() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAvailability(t *testing.T) {
+	one := []ha.Availability{{Topic: "test-topic-1"}}
+	two := []ha.Availability{{Topic: "test-topic-1"}, {Topic: "test-topic-2"}}
+
+	if got := WithAvailability(ha.Sensor{}, one).(ha.Sensor); !reflect.DeepEqual(got.Availability, one) || got.AvailabilityMode != "" {
+		t.Errorf("WithAvailability() = %v, %v, want %v, %v", got.Availability, got.AvailabilityMode, one, "")
+	}
+	if got := WithAvailability(ha.BinarySensor{}, two).(ha.BinarySensor); !reflect.DeepEqual(got.Availability, two) || got.AvailabilityMode != ha.All {
+		t.Errorf("WithAvailability() = %v, %v, want %v, %v", got.Availability, got.AvailabilityMode, two, ha.All)
+	}
+	if got := EntityAvailability(WithAvailability(ha.DeviceTracker{}, two)); !reflect.DeepEqual(got, two) {
+		t.Errorf("EntityAvailability() = %v, want %v", got, two)
+	}
+}
+
+func TestEntities_Availability(t *testing.T) {
+	d := ha.Device{Identifiers: []string{"test-prefix/cars/1"}}
+
+	for _, e := range Entities(d, ha.Config{AvailabilityTopic: "test-availability-topic"}, units.Config{}) {
+		a := EntityAvailability(e)
+		if len(a) == 0 || a[len(a)-1].Topic != "test-availability-topic" {
+			t.Errorf("Entities() availability = %v, want application availability last", a)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/tm"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+type bridgedVehicle struct {
+	vehicle tm.Vehicle
+	topics  map[string]bool
+	pending map[string][]byte
+	relayed map[string]string
+}
+
+func (m *MQTT) Bridge(ctx context.Context, destination *MQTT, tmCfg tm.Config, unitsCfg units.Config) error {
+	topic := fmt.Sprintf("%s/cars/+/+", tmCfg.Prefix)
+	fmt.Printf("Bridging %s\n", topic)
+
+	in, err := m.Subscribe(ctx, topic, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.Unsubscribe(ctx, topic) }()
+
+	vehicles := make(map[string]*bridgedVehicle)
+	r := VehicleTopicRegexp(tmCfg)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case msg := <-in:
+			s := r.FindStringSubmatch(msg.Topic())
+			if s == nil {
+				continue
+			}
+
+			id := s[1]
+			b, ok := vehicles[id]
+			if !ok {
+				b = &bridgedVehicle{
+					topics:  BridgedTopics(tmCfg, unitsCfg, id),
+					pending: make(map[string][]byte),
+					relayed: make(map[string]string),
+				}
+				vehicles[id] = b
+			}
+
+			b.vehicle.Set(s[2], string(msg.Payload()))
+			if b.topics[msg.Topic()] {
+				b.pending[msg.Topic()] = msg.Payload()
+			}
+
+			// state is held back until a vehicle's name is known to pass the filters, then relayed all at once
+			match, _, err := tmCfg.MatchVehicle(id, b.vehicle.Device(tmCfg, id).Name)
+			if err != nil {
+				return err
+			}
+			if !match {
+				continue
+			}
+
+			topics := make([]string, 0, len(b.pending))
+			for t := range b.pending {
+				topics = append(topics, t)
+			}
+			sort.Strings(topics)
+
+			for _, t := range topics {
+				p := b.pending[t]
+				delete(b.pending, t)
+
+				// skipping repeats keeps brokers that turn out to be the same from relaying their own messages forever
+				if r, ok := b.relayed[t]; ok && r == string(p) {
+					continue
+				}
+
+				if err := destination.Relay(ctx, t, p); err != nil {
+					return err
+				}
+				b.relayed[t] = string(p)
+			}
+		}
+	}
+}
+
+// Relay publishes TeslaMate state as retained, as TeslaMate does, even though the broker delivers live updates to the
+// bridge without the retained flag.
+func (m *MQTT) Relay(ctx context.Context, topic string, payload []byte) error {
+	t := m.Client.Publish(topic, 0, true, payload)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.Done():
+		return t.Error()
+	}
+}
+
+func BridgedTopics(tmCfg tm.Config, unitsCfg units.Config, id string) map[string]bool {
+	topics := make(map[string]bool)
+
+	for _, e := range Entities(tm.Vehicle{}.Device(tmCfg, id), ha.Config{}, unitsCfg) {
+		for _, t := range EntityTopics(e) {
+			topics[t] = true
+		}
+	}
+
+	return topics
+}
+
+func EntityTopics(v interface{}) []string {
+	var topics []string
+
+	if e, ok := v.(ha.Entity); ok {
+		topics = append(topics, e.Topics()...)
+	}
+
+	for _, a := range EntityAvailability(v) {
+		topics = append(topics, a.Topic)
+	}
+
+	var nonEmpty []string
+	for _, t := range topics {
+		if t != "" {
+			nonEmpty = append(nonEmpty, t)
+		}
+	}
+
+	return nonEmpty
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/tm"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestMQTT_Bridge(t *testing.T) {
+	type args struct {
+		tmCfg    tm.Config
+		messages []*stubMessage
+	}
+	tests := []struct {
+		name    string
+		args    args
+		tokens  []paho.Token
+		want    []publishArgs
+		wantErr bool
+	}{
+		{
+			name: "relays referenced topics",
+			args: args{
+				tmCfg: tm.Config{Prefix: "test-prefix"},
+				messages: []*stubMessage{
+					{topic: "test-prefix/cars/1/battery_level", payload: []byte("80")},
+					{topic: "test-prefix/cars/1/unreferenced", payload: []byte("test-value")},
+					{topic: "test-prefix/cars/1/battery_level", payload: []byte("80")},
+					{topic: "test-prefix/cars/1/battery_level", payload: []byte("79")},
+				},
+			},
+			tokens: []paho.Token{&stubToken{}},
+			want: []publishArgs{
+				{topic: "test-prefix/cars/1/battery_level", retained: true, payload: []byte("80")},
+				{topic: "test-prefix/cars/1/battery_level", retained: true, payload: []byte("79")},
+			},
+		},
+		{
+			name: "holds state until vehicle matches",
+			args: args{
+				tmCfg: tm.Config{Prefix: "test-prefix", Vehicles: []string{"test-name-1"}},
+				messages: []*stubMessage{
+					{topic: "test-prefix/cars/1/battery_level", payload: []byte("80")},
+					{topic: "test-prefix/cars/2/battery_level", payload: []byte("70")},
+					{topic: "test-prefix/cars/2/display_name", payload: []byte("test-name-2")},
+					{topic: "test-prefix/cars/1/display_name", payload: []byte("test-name-1")},
+				},
+			},
+			tokens: []paho.Token{&stubToken{}},
+			want: []publishArgs{
+				{topic: "test-prefix/cars/1/battery_level", retained: true, payload: []byte("80")},
+				{topic: "test-prefix/cars/1/display_name", retained: true, payload: []byte("test-name-1")},
+			},
+		},
+		{
+			name: "publish error",
+			args: args{
+				tmCfg: tm.Config{Prefix: "test-prefix"},
+				messages: []*stubMessage{
+					{topic: "test-prefix/cars/1/battery_level", payload: []byte("80")},
+				},
+			},
+			tokens: []paho.Token{&stubToken{err: fmt.Errorf("publish error")}},
+			want: []publishArgs{
+				{topic: "test-prefix/cars/1/battery_level", retained: true, payload: []byte("80")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			source := &stubPubSub{
+				subscribeHandler: func(cb paho.MessageHandler) {
+					for _, m := range tt.args.messages {
+						cb(nil, m)
+					}
+				},
+				subscribeTokens:   []paho.Token{&stubToken{}},
+				unsubscribeTokens: []paho.Token{&stubToken{}},
+			}
+
+			// messages are buffered by the subscription, so the bridge is stopped once it has relayed what it should,
+			// unless a failure to relay stops it first
+			destination := &stubPubSub{
+				publishHandler: func(count int) {
+					if count == len(tt.want) && !tt.wantErr {
+						cancel()
+					}
+				},
+				publishTokens: tt.tokens,
+			}
+
+			m := &MQTT{Client: source}
+			err := m.Bridge(ctx, &MQTT{Client: destination}, tt.args.tmCfg, units.Config{})
+			// the daemon ignores the bridge being stopped part way through relaying, as it is here
+			if errors.Is(err, context.Canceled) {
+				err = nil
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.Bridge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got := source.subscribeArgs[0].topic; got != "test-prefix/cars/+/+" {
+				t.Errorf("MQTT.Bridge() topic = %v, want %v", got, "test-prefix/cars/+/+")
+			}
+
+			if !reflect.DeepEqual(destination.publishArgs, tt.want) {
+				t.Errorf("MQTT.Bridge() published = %v, want %v", destination.publishArgs, tt.want)
+			}
+		})
+	}
+}
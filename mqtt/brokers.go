@@ -0,0 +1,102 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var DefaultSchemePorts = map[string]int{
+	"mqtt":     1883,
+	"tcp":      1883,
+	"mqtts":    8883,
+	"mqtt+ssl": 8883,
+	"ssl":      8883,
+	"tcps":     8883,
+	"tls":      8883,
+	"ws":       80,
+	"wss":      443,
+}
+
+func BrokerURLs(config Config) ([]*url.URL, error) {
+	raw := config.URLs
+	if len(raw) == 0 {
+		raw = []string{BrokerURI(config)}
+	}
+
+	var urls []*url.URL
+	for _, s := range raw {
+		u, err := ParseBrokerURL(s)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+
+	return urls, nil
+}
+
+func ParseBrokerURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		// the url error repeats the input, which may contain a password
+		return nil, fmt.Errorf("unable to parse mqtt url %s", RedactURL(s))
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	port, ok := DefaultSchemePorts[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("mqtt url %s must use one of the schemes mqtt, mqtts, tcp, ssl, tls, ws, wss", RedactURL(s))
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("mqtt url %s must include a host", RedactURL(s))
+	}
+
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	}
+
+	return u, nil
+}
+
+func BrokerCredentials(config Config, u *url.URL) (string, string) {
+	username, password := config.Username, config.Password
+
+	if u.User != nil {
+		username = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+
+	return username, password
+}
+
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			if j := strings.Index(s, "://"); j >= 0 && j < i {
+				return s[:j+3] + s[i+1:]
+			}
+			return s[i+1:]
+		}
+		return s
+	}
+
+	u.User = nil
+	return u.String()
+}
+
+func RedactURLs(urls []*url.URL) string {
+	s := make([]string, 0, len(urls))
+	for _, u := range urls {
+		s = append(s, RedactURL(u.String()))
+	}
+	return strings.Join(s, ", ")
+}
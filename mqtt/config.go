@@ -3,22 +3,160 @@
 
 package mqtt
 
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
 const (
-	DefaultScheme = "ssl"
-	DefaultHost   = "127.0.0.1"
-	DefaultPort   = 8883
+	DefaultScheme        = "ssl"
+	DefaultHost          = "127.0.0.1"
+	DefaultPort          = 8883
+	DefaultProtocol      = 3
+	DefaultClientID      = "teslamate-discovery"
+	DefaultSessionExpiry = 24 * time.Hour
+	DefaultQoS           = 1
+	DefaultInFlight      = 10
+	DefaultAckTimeout    = 10 * time.Second
+	DefaultReconnectMax  = 2 * time.Minute
+	DefaultTLSMinVersion = "1.2"
 )
 
+var DefaultUserProperties = []string{"publisher=teslamate-discovery"}
+
 var DefaultConfig = Config{
-	Scheme: DefaultScheme,
-	Host:   DefaultHost,
-	Port:   DefaultPort,
+	Scheme:         DefaultScheme,
+	Host:           DefaultHost,
+	Port:           DefaultPort,
+	Protocol:       DefaultProtocol,
+	ClientID:       DefaultClientID,
+	ClientIDSuffix: true,
+	CleanSession:   true,
+	SessionExpiry:  DefaultSessionExpiry,
+	QoS:            DefaultQoS,
+	InFlight:       DefaultInFlight,
+	AckTimeout:     DefaultAckTimeout,
+	ReconnectMax:   DefaultReconnectMax,
+	UserProperties: DefaultUserProperties,
+	TLS: TLSConfig{
+		MinVersion: DefaultTLSMinVersion,
+	},
 }
 
 type Config struct {
-	Scheme   string `mapstructure:"scheme"`
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	URLs           []string        `mapstructure:"urls"`
+	Scheme         string          `mapstructure:"scheme"`
+	Host           string          `mapstructure:"host"`
+	Port           int             `mapstructure:"port"`
+	Username       string          `mapstructure:"username"`
+	UsernameFile   string          `mapstructure:"username_file"`
+	Password       string          `mapstructure:"password"`
+	PasswordFile   string          `mapstructure:"password_file"`
+	Anonymous      bool            `mapstructure:"anonymous"`
+	Protocol       int             `mapstructure:"protocol"`
+	ClientID       string          `mapstructure:"client_id"`
+	ClientIDSuffix bool            `mapstructure:"client_id_suffix"`
+	CleanSession   bool            `mapstructure:"clean_session"`
+	SessionExpiry  time.Duration   `mapstructure:"session_expiry"`
+	QoS            int             `mapstructure:"qos"`
+	NoRetain       bool            `mapstructure:"no_retain"`
+	InFlight       int             `mapstructure:"in_flight"`
+	AckTimeout     time.Duration   `mapstructure:"ack_timeout"`
+	ReconnectMax   time.Duration   `mapstructure:"reconnect_max"`
+	MessageExpiry  time.Duration   `mapstructure:"message_expiry"`
+	UserProperties []string        `mapstructure:"user_properties"`
+	TLS            TLSConfig       `mapstructure:"tls"`
+	WebSocket      WebSocketConfig `mapstructure:"websocket"`
+
+	// AvailabilityTopic is set by the application, rather than configured, for the connection that publishes
+	// discovery configuration while it keeps running
+	AvailabilityTopic string `mapstructure:"-"`
+}
+
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	MinVersion         string `mapstructure:"min_version"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type WebSocketConfig struct {
+	Path    string   `mapstructure:"path"`
+	Headers []string `mapstructure:"headers"`
+	Proxy   string   `mapstructure:"proxy"`
+}
+
+func (c Config) Validate() error {
+	urls, err := BrokerURLs(c)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range urls {
+		username, password := BrokerCredentials(c, u)
+
+		// anonymous connections are explicit so that a missing secret is never mistaken for one
+		if c.Anonymous {
+			if username != "" || password != "" || c.UsernameFile != "" || c.PasswordFile != "" {
+				return fmt.Errorf("mqtt anonymous connections must not specify a username or password%s", BrokerSuffix(c, u))
+			}
+			continue
+		}
+
+		if username == "" && c.UsernameFile == "" {
+			return fmt.Errorf("mqtt username must be specified%s", BrokerSuffix(c, u))
+		}
+
+		if password == "" && c.PasswordFile == "" {
+			return fmt.Errorf("mqtt password must be specified%s", BrokerSuffix(c, u))
+		}
+	}
+
+	switch c.Protocol {
+	case 0, 3, 5:
+	default:
+		return fmt.Errorf("mqtt protocol must be one of 3, 5")
+	}
+
+	// a random suffix would leave the broker holding a session that no later connection can resume
+	if !c.CleanSession && c.ClientIDSuffix {
+		return fmt.Errorf("mqtt persistent sessions require a client id without a random suffix")
+	}
+
+	if c.SessionExpiry < 0 {
+		return fmt.Errorf("mqtt session expiry must not be negative")
+	}
+
+	if c.QoS < 0 || c.QoS > 2 {
+		return fmt.Errorf("mqtt qos must be one of 0, 1, 2")
+	}
+
+	if c.InFlight < 0 {
+		return fmt.Errorf("mqtt in-flight window must not be negative")
+	}
+
+	if c.AckTimeout < 0 {
+		return fmt.Errorf("mqtt acknowledgement timeout must not be negative")
+	}
+
+	if c.ReconnectMax < 0 {
+		return fmt.Errorf("mqtt maximum reconnect interval must not be negative")
+	}
+
+	if c.MessageExpiry < 0 {
+		return fmt.Errorf("mqtt message expiry must not be negative")
+	}
+
+	return nil
+}
+
+func BrokerSuffix(c Config, u *url.URL) string {
+	if len(c.URLs) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" for %s", RedactURL(u.String()))
 }
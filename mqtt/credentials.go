@@ -0,0 +1,139 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ReadCredentials replaces the username and password files in config with their contents, so that secrets mounted by
+// Docker or Kubernetes need not appear in flags or the environment.
+func ReadCredentials(config Config) (Config, error) {
+	var err error
+
+	if config.Username, err = ReadCredential("username", config.Username, config.UsernameFile); err != nil {
+		return config, err
+	}
+	config.UsernameFile = ""
+
+	if config.Password, err = ReadCredential("password", config.Password, config.PasswordFile); err != nil {
+		return config, err
+	}
+	config.PasswordFile = ""
+
+	return config, nil
+}
+
+func ReadCredential(name string, value string, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	if value != "" {
+		return "", fmt.Errorf("mqtt %s and %s file must not both be specified", name, name)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		// the path error names the file but never its contents
+		return "", fmt.Errorf("unable to read mqtt %s file: %w", name, err)
+	}
+
+	// secret files are commonly written with a trailing newline that is not part of the secret
+	s := strings.TrimRight(string(b), "\r\n")
+	if s == "" {
+		return "", fmt.Errorf("mqtt %s file %s is empty", name, file)
+	}
+
+	return s, nil
+}
+
+// ExclusiveCredentials lists, for each credential, the credentials that must not be combined with it, so that a broker
+// that specifies its credentials one way does not inherit them specified another.
+var ExclusiveCredentials = map[string][]string{
+	"anonymous":     {"username", "username_file", "password", "password_file"},
+	"username":      {"anonymous", "username_file"},
+	"username_file": {"anonymous", "username"},
+	"password":      {"anonymous", "password_file"},
+	"password_file": {"anonymous", "password"},
+}
+
+// ConflictingCredential reports whether inheriting key would combine it with a credential that the broker's own
+// configuration, looked up by value, already specifies.
+func ConflictingCredential(key string, value func(key string) string) bool {
+	for _, k := range ExclusiveCredentials[key] {
+		if v := value(k); v != "" && v != "false" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redactor removes secrets from text that originates outside this package, such as errors from the client or the
+// network that may repeat a broker url.
+type Redactor []string
+
+func NewRedactor(config Config, urls []*url.URL) Redactor {
+	var r Redactor
+
+	add := func(s string) {
+		if s == "" {
+			return
+		}
+
+		r = append(r, s)
+
+		// a url prints its password escaped as userinfo, which escapes characters such as @ and : that paths do not
+		if _, e, _ := strings.Cut(url.UserPassword("", s).String(), ":"); e != s {
+			r = append(r, e)
+		}
+	}
+
+	add(config.Password)
+	for _, u := range urls {
+		_, password := BrokerCredentials(config, u)
+		add(password)
+	}
+
+	// a proxy's credentials appear in the errors of connecting through it
+	if u, err := url.Parse(config.WebSocket.Proxy); err == nil {
+		password, _ := u.User.Password()
+		add(password)
+	}
+
+	return r
+}
+
+func (r Redactor) Redact(s string) string {
+	for _, secret := range r {
+		s = strings.ReplaceAll(s, secret, "[REDACTED]")
+	}
+	return s
+}
+
+func (r Redactor) Error(err error) error {
+	if err == nil || len(r) == 0 {
+		return err
+	}
+
+	return &RedactedError{Err: err, Redactor: r}
+}
+
+// RedactedError hides secrets in the message of the error it wraps while leaving the error available for inspection.
+type RedactedError struct {
+	Err      error
+	Redactor Redactor
+}
+
+func (r *RedactedError) Error() string {
+	return r.Redactor.Redact(r.Err.Error())
+}
+
+func (r *RedactedError) Unwrap() error {
+	return r.Err
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestReadCredentials(t *testing.T) {
+	dir := t.TempDir()
+
+	username := filepath.Join(dir, "username")
+	if err := os.WriteFile(username, []byte("test-username\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	password := filepath.Join(dir, "password")
+	if err := os.WriteFile(password, []byte("test-password\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		config       Config
+		wantUsername string
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name:         "values",
+			config:       Config{Username: "test-username", Password: "test-password"},
+			wantUsername: "test-username",
+			wantPassword: "test-password",
+		},
+		{
+			name:         "files",
+			config:       Config{UsernameFile: username, PasswordFile: password},
+			wantUsername: "test-username",
+			wantPassword: "test-password",
+		},
+		{
+			name:         "value and file",
+			config:       Config{Username: "test-username", PasswordFile: password},
+			wantUsername: "test-username",
+			wantPassword: "test-password",
+		},
+		{
+			name:    "password and password file",
+			config:  Config{Password: "test-password", PasswordFile: password},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			config:  Config{Username: "test-username", PasswordFile: filepath.Join(dir, "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			config:  Config{Username: "test-username", PasswordFile: empty},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCredentials(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadCredentials() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Username != tt.wantUsername || got.Password != tt.wantPassword {
+				t.Errorf("ReadCredentials() = %s/%s, want %s/%s", got.Username, got.Password, tt.wantUsername, tt.wantPassword)
+			}
+			if got.UsernameFile != "" || got.PasswordFile != "" {
+				t.Errorf("ReadCredentials() files = %s/%s, want none", got.UsernameFile, got.PasswordFile)
+			}
+		})
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "plain", password: "test-password"},
+		{name: "slash", password: "test/password"},
+		{name: "at", password: "test@password"},
+		{name: "colon", password: "test:password"},
+		{name: "space", password: "test password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &url.URL{Scheme: "mqtts", Host: "test-host:4242", User: url.UserPassword("test-username", "url-"+tt.password)}
+			r := NewRedactor(Config{Password: tt.password}, []*url.URL{u})
+
+			cause := fmt.Errorf("test-cause")
+			err := r.Error(fmt.Errorf("dial %s with %s and url-%s: %w", u, tt.password, tt.password, cause))
+
+			if got := err.Error(); strings.Contains(got, "password") {
+				t.Errorf("Redactor.Error() = %s, want no passwords", got)
+			}
+			if !errors.Is(err, cause) {
+				t.Errorf("Redactor.Error() does not wrap %v", cause)
+			}
+		})
+	}
+
+	p := &url.URL{Scheme: "http", Host: "test-proxy:3128", User: url.UserPassword("test-username", "test-proxy@password")}
+	r := NewRedactor(Config{WebSocket: WebSocketConfig{Proxy: p.String()}}, nil)
+	if got := r.Redact(fmt.Sprintf("proxyconnect %s", p)); strings.Contains(got, "password") {
+		t.Errorf("Redactor.Redact() = %s, want no proxy password", got)
+	}
+
+	if r := NewRedactor(Config{Password: "test-password"}, nil); r.Error(nil) != nil {
+		t.Errorf("Redactor.Error(nil) = %v, want nil", r.Error(nil))
+	}
+}
+
+func TestConflictingCredential(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		broker map[string]string
+		want   bool
+	}{
+		{name: "nothing set", key: "password", want: false},
+		{name: "same key set", key: "password", broker: map[string]string{"password": "test-password"}, want: false},
+		{name: "file set", key: "password", broker: map[string]string{"password_file": "test-file"}, want: true},
+		{name: "value set", key: "password_file", broker: map[string]string{"password": "test-password"}, want: true},
+		{name: "anonymous set", key: "username", broker: map[string]string{"anonymous": "true"}, want: true},
+		{name: "anonymous unset", key: "username", broker: map[string]string{"anonymous": "false"}, want: false},
+		{name: "credential set", key: "anonymous", broker: map[string]string{"username_file": "test-file"}, want: true},
+		{name: "empty credential", key: "anonymous", broker: map[string]string{"username": ""}, want: false},
+		{name: "other key", key: "qos", broker: map[string]string{"password": "test-password"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConflictingCredential(tt.key, func(k string) string { return tt.broker[k] })
+			if got != tt.want {
+				t.Errorf("ConflictingCredential() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+func DeviceDiscoveryMessage(discoveryPrefix string, device ha.Device, origin ha.Origin, entities []interface{}) (Message, error) {
+	d := ha.DevicePayload{
+		Components: make(map[string]interface{}, len(entities)),
+		Device:     device,
+		Origin:     origin,
+	}
+
+	for _, e := range entities {
+		id, c, err := Component(device, e)
+		if err != nil {
+			return Message{}, err
+		}
+		d.Components[id] = c
+	}
+
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Topic: DeviceDiscoveryTopic(discoveryPrefix, device), Payload: payload}, nil
+}
+
+func DeviceDiscoveryTopic(discoveryPrefix string, device ha.Device) string {
+	return fmt.Sprintf("%s/device/%s/config", discoveryPrefix, NodeId(device))
+}
+
+// Component adapts an entity to a component of a device discovery payload, which shares the device and origin of the
+// payload and names its own platform in place of the discovery topic.
+func Component(device ha.Device, v interface{}) (string, interface{}, error) {
+	e, ok := v.(ha.Entity)
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected message type: %T", v)
+	}
+
+	c := e.Common()
+	c.Device, c.Origin, c.Platform = ha.Device{}, ha.Origin{}, e.Component()
+
+	return ObjectId(device, c.UniqueId), e.WithCommon(c), nil
+}
+
+func NodeId(device ha.Device) string {
+	return strings.ReplaceAll(device.Identifiers[0], "/", "_")
+}
+
+func ObjectId(device ha.Device, uniqueId string) string {
+	return strings.TrimPrefix(uniqueId, fmt.Sprintf("%s/", NodeId(device)))
+}
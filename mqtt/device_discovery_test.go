@@ -0,0 +1,74 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestDiscoveryMessages_Device(t *testing.T) {
+	d := ha.Device{
+		Identifiers: []string{"test-prefix/cars/1"},
+		Name:        "test-name",
+	}
+	haCfg := ha.Config{DiscoveryMode: ha.DeviceDiscovery, DiscoveryPrefix: "test-discovery-prefix"}
+
+	got, err := DiscoveryMessages(d, haCfg, units.Config{})
+	if err != nil {
+		t.Fatalf("DiscoveryMessages() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("DiscoveryMessages() count = %d, want 1", len(got))
+	}
+	if want := "test-discovery-prefix/device/test-prefix_cars_1/config"; got[0].Topic != want {
+		t.Errorf("DiscoveryMessages() topic = %v, want %v", got[0].Topic, want)
+	}
+
+	var p struct {
+		Components map[string]map[string]interface{} `json:"components"`
+		Device     ha.Device                         `json:"device"`
+		Origin     ha.Origin                         `json:"origin"`
+	}
+	if err := json.Unmarshal(got[0].Payload, &p); err != nil {
+		t.Fatalf("DiscoveryMessages() payload error = %v", err)
+	}
+
+	if len(p.Components) != len(Entities(d, haCfg, units.Config{})) {
+		t.Errorf("DiscoveryMessages() components = %d, want %d", len(p.Components), len(Entities(d, haCfg, units.Config{})))
+	}
+	if p.Device.Name != d.Name {
+		t.Errorf("DiscoveryMessages() device name = %v, want %v", p.Device.Name, d.Name)
+	}
+	if want := (ha.Origin{Name: OriginName, SupportURL: OriginSupportURL}); p.Origin != want {
+		t.Errorf("DiscoveryMessages() origin = %v, want %v", p.Origin, want)
+	}
+
+	c := p.Components["plug"]
+	if c["platform"] != "binary_sensor" {
+		t.Errorf("DiscoveryMessages() plug platform = %v, want %v", c["platform"], "binary_sensor")
+	}
+	if c["unique_id"] != "test-prefix_cars_1/plug" {
+		t.Errorf("DiscoveryMessages() plug unique id = %v, want %v", c["unique_id"], "test-prefix_cars_1/plug")
+	}
+	if _, ok := c["device"]; ok {
+		t.Errorf("DiscoveryMessages() plug device = %v, want shared device", c["device"])
+	}
+	if _, ok := c["origin"]; ok {
+		t.Errorf("DiscoveryMessages() plug origin = %v, want shared origin", c["origin"])
+	}
+}
+
+func TestDiscoveryMessages_InvalidMode(t *testing.T) {
+	d := ha.Device{Identifiers: []string{"test-prefix/cars/1"}}
+
+	if _, err := DiscoveryMessages(d, ha.Config{DiscoveryMode: "test-mode"}, units.Config{}); err == nil {
+		t.Errorf("DiscoveryMessages() error = nil, want error")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+type DiscoveryDiff struct {
+	Added   []Message
+	Removed []Message
+	Changed []ChangedMessage
+}
+
+func (d DiscoveryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// UnparseableRetainedPayload is the reason given for a change when the retained payload cannot be compared field by
+// field.
+const UnparseableRetainedPayload = "unparseable retained payload"
+
+type ChangedMessage struct {
+	Topic  string
+	Fields []FieldDiff
+	Reason string
+}
+
+type FieldOp string
+
+const (
+	FieldAdded   FieldOp = "+"
+	FieldRemoved FieldOp = "-"
+	FieldChanged FieldOp = "~"
+)
+
+type FieldDiff struct {
+	Op   FieldOp
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func Diff(retained []RetainedConfig, rendered []Message) (DiscoveryDiff, error) {
+	var d DiscoveryDiff
+
+	existing := make(map[string][]byte, len(retained))
+	for _, r := range retained {
+		existing[r.Topic] = r.Payload
+	}
+
+	current := make(map[string]bool, len(rendered))
+	for _, m := range rendered {
+		current[m.Topic] = true
+
+		old, ok := existing[m.Topic]
+		if !ok {
+			d.Added = append(d.Added, m)
+			continue
+		}
+
+		// a retained payload that is not json, such as one published by hand, is replaced like any other change
+		if !json.Valid(old) {
+			d.Changed = append(d.Changed, ChangedMessage{Topic: m.Topic, Reason: UnparseableRetainedPayload})
+			continue
+		}
+
+		f, err := DiffPayloads(old, m.Payload)
+		if err != nil {
+			return DiscoveryDiff{}, err
+		}
+		if len(f) > 0 {
+			d.Changed = append(d.Changed, ChangedMessage{Topic: m.Topic, Fields: f})
+		}
+	}
+
+	for _, r := range retained {
+		if !current[r.Topic] {
+			d.Removed = append(d.Removed, Message{Topic: r.Topic, Payload: r.Payload})
+		}
+	}
+
+	return d, nil
+}
+
+func DiffPayloads(old []byte, new []byte) ([]FieldDiff, error) {
+	var o, n interface{}
+
+	if err := json.Unmarshal(old, &o); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(new, &n); err != nil {
+		return nil, err
+	}
+
+	var f []FieldDiff
+	diffValues("", o, n, &f)
+
+	sort.SliceStable(f, func(i, j int) bool {
+		return f[i].Path < f[j].Path
+	})
+
+	return f, nil
+}
+
+func diffValues(path string, old interface{}, new interface{}, f *[]FieldDiff) {
+	o, oOk := old.(map[string]interface{})
+	n, nOk := new.(map[string]interface{})
+
+	if !oOk || !nOk {
+		if !reflect.DeepEqual(old, new) {
+			*f = append(*f, FieldDiff{Op: FieldChanged, Path: path, Old: old, New: new})
+		}
+		return
+	}
+
+	for k, ov := range o {
+		p := joinPath(path, k)
+		if nv, ok := n[k]; ok {
+			diffValues(p, ov, nv, f)
+		} else {
+			*f = append(*f, FieldDiff{Op: FieldRemoved, Path: p, Old: ov})
+		}
+	}
+
+	for k, nv := range n {
+		if _, ok := o[k]; !ok {
+			*f = append(*f, FieldDiff{Op: FieldAdded, Path: joinPath(path, k), New: nv})
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestDiff(t *testing.T) {
+	type args struct {
+		retained []RetainedConfig
+		rendered []Message
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    DiscoveryDiff
+		wantErr bool
+	}{
+		{
+			name: "identical",
+			args: args{
+				retained: []RetainedConfig{
+					{Topic: "test-topic", Payload: []byte(`{"a":"1","b":{"c":"2"}}`)},
+				},
+				rendered: []Message{
+					{Topic: "test-topic", Payload: []byte(`{"b":{"c":"2"},"a":"1"}`)},
+				},
+			},
+		},
+		{
+			name: "different",
+			args: args{
+				retained: []RetainedConfig{
+					{Topic: "test-topic-changed", Payload: []byte(`{"a":"1","b":{"c":"2"},"d":"3"}`)},
+					{Topic: "test-topic-removed", Payload: []byte(`{"a":"1"}`)},
+				},
+				rendered: []Message{
+					{Topic: "test-topic-changed", Payload: []byte(`{"a":"1","b":{"c":"4"},"e":"5"}`)},
+					{Topic: "test-topic-added", Payload: []byte(`{"a":"1"}`)},
+				},
+			},
+			want: DiscoveryDiff{
+				Added: []Message{
+					{Topic: "test-topic-added", Payload: []byte(`{"a":"1"}`)},
+				},
+				Removed: []Message{
+					{Topic: "test-topic-removed", Payload: []byte(`{"a":"1"}`)},
+				},
+				Changed: []ChangedMessage{
+					{
+						Topic: "test-topic-changed",
+						Fields: []FieldDiff{
+							{Op: FieldChanged, Path: "b.c", Old: "2", New: "4"},
+							{Op: FieldRemoved, Path: "d", Old: "3"},
+							{Op: FieldAdded, Path: "e", New: "5"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "unparseable retained",
+			args: args{
+				retained: []RetainedConfig{
+					{Topic: "test-topic-unparseable", Payload: []byte(`{`)},
+					{Topic: "test-topic-changed", Payload: []byte(`{"a":"1"}`)},
+				},
+				rendered: []Message{
+					{Topic: "test-topic-unparseable", Payload: []byte(`{}`)},
+					{Topic: "test-topic-changed", Payload: []byte(`{"a":"2"}`)},
+				},
+			},
+			want: DiscoveryDiff{
+				Changed: []ChangedMessage{
+					{Topic: "test-topic-unparseable", Reason: UnparseableRetainedPayload},
+					{
+						Topic: "test-topic-changed",
+						Fields: []FieldDiff{
+							{Op: FieldChanged, Path: "a", Old: "1", New: "2"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid rendered",
+			args: args{
+				retained: []RetainedConfig{
+					{Topic: "test-topic", Payload: []byte(`{}`)},
+				},
+				rendered: []Message{
+					{Topic: "test-topic", Payload: []byte(`{`)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.args.retained, tt.args.rendered)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+			if got.Empty() != reflect.DeepEqual(tt.want, DiscoveryDiff{}) {
+				t.Errorf("DiscoveryDiff.Empty() = %v", got.Empty())
+			}
+		})
+	}
+}
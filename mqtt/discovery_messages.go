@@ -0,0 +1,79 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+func DiscoveryMessages(device ha.Device, haCfg ha.Config, unitsCfg units.Config) ([]Message, error) {
+	entities := Entities(device, haCfg, unitsCfg)
+	origin := Origin(haCfg)
+
+	switch haCfg.DiscoveryMode {
+	case "", ha.EntityDiscovery:
+	case ha.DeviceDiscovery:
+		msg, err := DeviceDiscoveryMessage(haCfg.DiscoveryPrefix, device, origin, entities)
+		if err != nil {
+			return nil, err
+		}
+		return AbbreviateMessages(haCfg, device, []Message{msg})
+	default:
+		return nil, fmt.Errorf("ha discovery mode must be one of device, entity")
+	}
+
+	var messages []Message
+
+	for _, v := range entities {
+		msg, err := DiscoveryMessage(haCfg.DiscoveryPrefix, WithOrigin(v, origin))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return AbbreviateMessages(haCfg, device, messages)
+}
+
+// AbbreviateMessages abbreviates the payloads of messages when configured to, using the vehicle's TeslaMate topic as
+// the base topic.
+func AbbreviateMessages(haCfg ha.Config, device ha.Device, messages []Message) ([]Message, error) {
+	if !haCfg.Abbreviate {
+		return messages, nil
+	}
+
+	base := StateTopic(device, "")
+	for i, msg := range messages {
+		payload, err := Abbreviate(msg.Payload, base)
+		if err != nil {
+			return nil, err
+		}
+		messages[i].Payload = payload
+	}
+
+	return messages, nil
+}
+
+func DiscoveryMessage(discoveryPrefix string, v interface{}) (Message, error) {
+	topic, err := DiscoveryTopic(discoveryPrefix, v)
+	if err != nil {
+		return Message{}, err
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Topic: topic, Payload: payload}, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestDiscoveryMessages(t *testing.T) {
+	d := ha.Device{
+		Identifiers: []string{"test-prefix/cars/1"},
+		Name:        "test-name",
+	}
+
+	haCfg := ha.Config{
+		DiscoveryPrefix: "test-discovery-prefix",
+		Origin:          ha.Origin{SoftwareVersion: "test-version"},
+	}
+
+	got, err := DiscoveryMessages(d, haCfg, units.Config{})
+	if err != nil {
+		t.Errorf("DiscoveryMessages() error = %v", err)
+		return
+	}
+
+	if len(got) != len(Entities(d, ha.Config{}, units.Config{})) {
+		t.Errorf("DiscoveryMessages() count = %d, want %d", len(got), len(Entities(d, ha.Config{}, units.Config{})))
+	}
+
+	if want := "test-discovery-prefix/sensor/test-prefix_cars_1/charge_current_request/config"; got[0].Topic != want {
+		t.Errorf("DiscoveryMessages() topic = %v, want %v", got[0].Topic, want)
+	}
+
+	var s ha.Sensor
+	if err := json.Unmarshal(got[0].Payload, &s); err != nil {
+		t.Errorf("DiscoveryMessages() payload error = %v", err)
+		return
+	}
+
+	if want := "test-prefix/cars/1/charge_current_request"; s.StateTopic != want {
+		t.Errorf("DiscoveryMessages() state topic = %v, want %v", s.StateTopic, want)
+	}
+	if s.Device.Name != d.Name {
+		t.Errorf("DiscoveryMessages() device name = %v, want %v", s.Device.Name, d.Name)
+	}
+
+	want := ha.Origin{Name: OriginName, SoftwareVersion: "test-version", SupportURL: OriginSupportURL}
+	for _, m := range got {
+		var p struct {
+			Origin ha.Origin `json:"origin"`
+		}
+		if err := json.Unmarshal(m.Payload, &p); err != nil {
+			t.Fatalf("DiscoveryMessages() payload error = %v", err)
+		}
+		if p.Origin != want {
+			t.Errorf("DiscoveryMessages() %s origin = %v, want %v", m.Topic, p.Origin, want)
+		}
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin ha.Origin
+		want   ha.Origin
+	}{
+		{
+			name: "defaults",
+			want: ha.Origin{Name: OriginName, SupportURL: OriginSupportURL},
+		},
+		{
+			name:   "version",
+			origin: ha.Origin{SoftwareVersion: "test-version"},
+			want:   ha.Origin{Name: OriginName, SoftwareVersion: "test-version", SupportURL: OriginSupportURL},
+		},
+		{
+			name:   "configured",
+			origin: ha.Origin{Name: "test-name", SoftwareVersion: "test-version", SupportURL: "test-support-url"},
+			want:   ha.Origin{Name: "test-name", SoftwareVersion: "test-version", SupportURL: "test-support-url"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Origin(ha.Config{Origin: tt.origin}); got != tt.want {
+				t.Errorf("Origin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
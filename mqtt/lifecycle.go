@@ -0,0 +1,160 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const InitialReconnectInterval = time.Second
+
+// NewReconnectBackoff doubles the delay between rounds of reconnection attempts, from one second up to limit, matching
+// the backoff built into the MQTT 3 client.
+func NewReconnectBackoff(limit time.Duration) func(attempt int) time.Duration {
+	limit = max(limit, InitialReconnectInterval)
+
+	return func(attempt int) time.Duration {
+		if attempt <= 0 {
+			return 0
+		}
+
+		d := InitialReconnectInterval
+		for i := 1; i < attempt && d < limit; i++ {
+			d *= 2
+		}
+
+		return min(d, limit)
+	}
+}
+
+func DisconnectReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(": %s", err)
+}
+
+// Session carries what a client needs, beyond its configuration, to keep a session going across connections.
+type Session struct {
+	ClientID     string
+	Disconnected chan struct{}
+	Reconnected  func()
+	Queued       paho.MessageHandler
+	Will         *Message
+}
+
+// MaxQueuedMessages bounds how many messages from a resumed session are held for subscriptions not yet made again.
+const MaxQueuedMessages = 1000
+
+type subscription struct {
+	qos      byte
+	callback paho.MessageHandler
+}
+
+// ResubscribingPubSub remembers active subscriptions so that they can be restored when a connection is re-established
+// without the session that held them.
+type ResubscribingPubSub struct {
+	Client PubSub
+
+	mu            sync.Mutex
+	subscriptions map[string]subscription
+	queued        []paho.Message
+}
+
+func (r *ResubscribingPubSub) Session(clientID string) Session {
+	return Session{
+		ClientID:     clientID,
+		Disconnected: make(chan struct{}),
+		Reconnected:  func() { r.Resubscribe() },
+		Queued:       r.Queue,
+	}
+}
+
+func (r *ResubscribingPubSub) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	return r.Client.Publish(topic, qos, retained, payload)
+}
+
+func (r *ResubscribingPubSub) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	r.mu.Lock()
+	if r.subscriptions == nil {
+		r.subscriptions = make(map[string]subscription)
+	}
+	r.subscriptions[topic] = subscription{qos: qos, callback: callback}
+
+	var matched []paho.Message
+	queued := r.queued[:0]
+	for _, m := range r.queued {
+		if MatchTopic(topic, m.Topic()) {
+			matched = append(matched, m)
+		} else {
+			queued = append(queued, m)
+		}
+	}
+	r.queued = queued
+	r.mu.Unlock()
+
+	// queued messages are older than any the broker sends for the new subscription, such as retained messages, so they
+	// are delivered first
+	for _, m := range matched {
+		callback(nil, m)
+	}
+
+	return r.Client.Subscribe(topic, qos, callback)
+}
+
+// Queue receives messages that match none of the client's subscriptions, which happens when a resumed session
+// delivers messages queued while disconnected before they have been subscribed to again.
+func (r *ResubscribingPubSub) Queue(c paho.Client, m paho.Message) {
+	r.mu.Lock()
+
+	for topic, s := range r.subscriptions {
+		if MatchTopic(topic, m.Topic()) {
+			r.mu.Unlock()
+			s.callback(c, m)
+			return
+		}
+	}
+
+	if len(r.queued) < MaxQueuedMessages {
+		r.queued = append(r.queued, m)
+	}
+	r.mu.Unlock()
+}
+
+func (r *ResubscribingPubSub) Unsubscribe(topics ...string) paho.Token {
+	r.mu.Lock()
+	for _, t := range topics {
+		delete(r.subscriptions, t)
+	}
+	r.mu.Unlock()
+
+	return r.Client.Unsubscribe(topics...)
+}
+
+func (r *ResubscribingPubSub) Resubscribe() []paho.Token {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []paho.Token
+	for topic, s := range r.subscriptions {
+		fmt.Printf("Resubscribing to %s\n", topic)
+
+		t := r.Client.Subscribe(topic, s.qos, s.callback)
+		go func(topic string) {
+			<-t.Done()
+			if err := t.Error(); err != nil {
+				fmt.Printf("Unable to resubscribe to %s: %s\n", topic, err)
+			}
+		}(topic)
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens
+}
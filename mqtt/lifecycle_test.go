@@ -0,0 +1,166 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestNewReconnectBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first attempt", limit: time.Minute, attempt: 0, want: 0},
+		{name: "second attempt", limit: time.Minute, attempt: 1, want: time.Second},
+		{name: "doubled", limit: time.Minute, attempt: 3, want: 4 * time.Second},
+		{name: "limited", limit: time.Minute, attempt: 10, want: time.Minute},
+		{name: "far beyond limit", limit: time.Minute, attempt: 1000, want: time.Minute},
+		{name: "limit below initial", limit: 0, attempt: 5, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewReconnectBackoff(tt.limit)(tt.attempt); got != tt.want {
+				t.Errorf("NewReconnectBackoff()() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisconnectReason(t *testing.T) {
+	if got := DisconnectReason(nil); got != "" {
+		t.Errorf("DisconnectReason() = %q, want %q", got, "")
+	}
+	if got := DisconnectReason(fmt.Errorf("test-reason")); got != ": test-reason" {
+		t.Errorf("DisconnectReason() = %q, want %q", got, ": test-reason")
+	}
+}
+
+func TestResubscribingPubSub(t *testing.T) {
+	s := newStubDroppingPubSub()
+	m := &MQTT{Client: &ResubscribingPubSub{Client: s}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	online := make(chan struct{}, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- m.WatchStatus(ctx, ha.Config{DiscoveryPrefix: "test-discovery-prefix"}, func(ctx context.Context) error {
+			online <- struct{}{}
+			return nil
+		})
+	}()
+
+	if got := <-s.subscribed; got != "test-discovery-prefix/status" {
+		t.Fatalf("subscribed = %s, want %s", got, "test-discovery-prefix/status")
+	}
+
+	// the broker drops the connection and the client reconnects without its session
+	s.Drop()
+	if s.Deliver("test-discovery-prefix/status", StatusOnline) {
+		t.Fatalf("message delivered after connection dropped")
+	}
+
+	for _, tok := range m.Client.(*ResubscribingPubSub).Resubscribe() {
+		<-tok.Done()
+	}
+	<-s.subscribed
+
+	if !s.Deliver("test-discovery-prefix/status", StatusOnline) {
+		t.Fatalf("message not delivered after resubscribe")
+	}
+
+	select {
+	case <-online:
+	case <-ctx.Done():
+		t.Fatalf("status not received after resubscribe")
+	}
+
+	cancel()
+	if err := <-errs; err != nil {
+		t.Errorf("MQTT.WatchStatus() error = %v", err)
+	}
+}
+
+func TestResubscribingPubSub_Unsubscribe(t *testing.T) {
+	s := newStubDroppingPubSub()
+	r := &ResubscribingPubSub{Client: s}
+
+	<-r.Subscribe("test-topic-1", 1, nil).Done()
+	<-r.Subscribe("test-topic-2", 1, nil).Done()
+	<-r.Unsubscribe("test-topic-1").Done()
+	<-s.subscribed
+	<-s.subscribed
+
+	s.Drop()
+	if got := len(r.Resubscribe()); got != 1 {
+		t.Errorf("ResubscribingPubSub.Resubscribe() = %d subscriptions, want 1", got)
+	}
+	if got := <-s.subscribed; got != "test-topic-2" {
+		t.Errorf("resubscribed = %s, want %s", got, "test-topic-2")
+	}
+}
+
+func TestResubscribingPubSub_Queue(t *testing.T) {
+	s := newStubDroppingPubSub()
+	r := &ResubscribingPubSub{Client: s}
+
+	// a resumed session delivers messages before anything has subscribed to them again
+	r.Queue(nil, &stubMessage{topic: "test-prefix/1/test-leaf", payload: []byte("test-payload-1")})
+	r.Queue(nil, &stubMessage{topic: "test-other/1", payload: []byte("test-payload-2")})
+
+	ch := make(chan paho.Message, 1)
+	<-r.Subscribe("test-prefix/+/test-leaf", 1, func(_ paho.Client, m paho.Message) { ch <- m }).Done()
+
+	if m := <-ch; string(m.Payload()) != "test-payload-1" {
+		t.Errorf("queued message = %s, want %s", m.Payload(), "test-payload-1")
+	}
+
+	go r.Queue(nil, &stubMessage{topic: "test-prefix/2/test-leaf", payload: []byte("test-payload-3")})
+	if m := <-ch; string(m.Payload()) != "test-payload-3" {
+		t.Errorf("queued message = %s, want %s", m.Payload(), "test-payload-3")
+	}
+
+	other := make(chan paho.Message, 1)
+	<-r.Subscribe("test-other/#", 1, func(_ paho.Client, m paho.Message) { other <- m }).Done()
+	if m := <-other; string(m.Payload()) != "test-payload-2" {
+		t.Errorf("queued message = %s, want %s", m.Payload(), "test-payload-2")
+	}
+}
+
+func TestResubscribingPubSub_QueuedBeforeRetained(t *testing.T) {
+	s := &stubPubSub{
+		subscribeHandler: func(callback paho.MessageHandler) {
+			callback(nil, &stubMessage{topic: "test-prefix/cars/1/display_name", payload: []byte("test-retained"), retained: true})
+		},
+		subscribeTokens: []paho.Token{&stubToken{}},
+	}
+	r := &ResubscribingPubSub{Client: s}
+	m := &MQTT{Client: r}
+
+	// a resumed session delivers a stale value before the broker sends the retained one
+	r.Queue(nil, &stubMessage{topic: "test-prefix/cars/1/display_name", payload: []byte("test-queued")})
+
+	ch, err := m.Subscribe(context.Background(), "test-prefix/cars/+/+", 0)
+	if err != nil {
+		t.Fatalf("MQTT.Subscribe() error = %v", err)
+	}
+
+	for _, want := range []string{"test-queued", "test-retained"} {
+		if got := <-ch; string(got.Payload()) != want {
+			t.Errorf("MQTT.Subscribe() = %s, want %s", got.Payload(), want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/tm"
+)
+
+type RetainedConfig struct {
+	Topic     string
+	VehicleId string
+	Payload   []byte
+}
+
+func (r RetainedConfig) DeviceName() string {
+	var p struct {
+		Device            ha.Device `json:"device"`
+		AbbreviatedDevice ha.Device `json:"dev"`
+	}
+
+	if err := json.Unmarshal(r.Payload, &p); err != nil {
+		return ""
+	}
+
+	if p.AbbreviatedDevice.Name != "" {
+		return p.AbbreviatedDevice.Name
+	}
+	return p.Device.Name
+}
+
+func (m *MQTT) ListDiscovery(ctx context.Context, haCfg ha.Config, tmCfg tm.Config) ([]RetainedConfig, error) {
+	fmt.Println("Listing Discovery Configurations")
+
+	// only the shapes of topic that entity and device configuration are published to, rather than everything else that
+	// shares the discovery prefix
+	entityTopic := fmt.Sprintf("%s/+/+/+/config", haCfg.DiscoveryPrefix)
+	deviceTopic := fmt.Sprintf("%s/device/+/config", haCfg.DiscoveryPrefix)
+
+	entities, err := m.Subscribe(ctx, entityTopic, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = m.Unsubscribe(ctx, entityTopic) }()
+
+	devices, err := m.Subscribe(ctx, deviceTopic, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = m.Unsubscribe(ctx, deviceTopic) }()
+
+	var configs []RetainedConfig
+	r := DiscoveryTopicRegexp(haCfg, tmCfg)
+
+	// a slow broker delivers retained configuration as slowly as it delivers vehicles, so it is waited for as long
+	idle := time.NewTimer(tmCfg.IdleTimeout)
+	defer idle.Stop()
+
+	for {
+		var msg paho.Message
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case msg = <-entities:
+		case msg = <-devices:
+
+		case <-idle.C:
+			sort.Slice(configs, func(i, j int) bool {
+				return configs[i].Topic < configs[j].Topic
+			})
+
+			return configs, nil
+		}
+
+		idle.Reset(tmCfg.IdleTimeout)
+
+		if len(msg.Payload()) == 0 {
+			continue
+		}
+
+		id, ok := DiscoveryVehicleId(r, msg.Topic())
+		if !ok {
+			continue
+		}
+
+		configs = append(configs, RetainedConfig{
+			Topic:     msg.Topic(),
+			VehicleId: id,
+			Payload:   msg.Payload(),
+		})
+	}
+}
+
+func DiscoveryTopicRegexp(haCfg ha.Config, tmCfg tm.Config) *regexp.Regexp {
+	node := regexp.QuoteMeta(strings.ReplaceAll(tmCfg.Prefix, "/", "_"))
+
+	return regexp.MustCompile(fmt.Sprintf(`^%s/(?:(?:binary_sensor|device_tracker|sensor|update)/%s_cars_(?P<entity>[\d]+)/[^/]+|device/%s_cars_(?P<device>[\d]+))/config$`,
+		regexp.QuoteMeta(haCfg.DiscoveryPrefix), node, node))
+}
+
+// DiscoveryVehicleId returns the id of the vehicle that a discovery topic matched by r configures, from whichever of
+// entity or device configuration it is.
+func DiscoveryVehicleId(r *regexp.Regexp, topic string) (string, bool) {
+	s := r.FindStringSubmatch(topic)
+	if s == nil {
+		return "", false
+	}
+
+	if id := s[r.SubexpIndex("entity")]; id != "" {
+		return id, true
+	}
+	return s[r.SubexpIndex("device")], true
+}
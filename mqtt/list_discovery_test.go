@@ -0,0 +1,187 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/tm"
+)
+
+func TestMQTT_ListDiscovery(t *testing.T) {
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		ctx   context.Context
+		haCfg ha.Config
+		tmCfg tm.Config
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []RetainedConfig
+		wantErr bool
+	}{
+		{
+			name: "default",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeMatching: true,
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/sensor/test-prefix_cars_2/range/config",
+							payload: []byte("test-payload-2"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/binary_sensor/test-prefix_cars_1/plug/config",
+							payload: []byte("test-payload-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/sensor/test-prefix_cars_1/cleared/config",
+							payload: []byte{},
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/sensor/other_device/range/config",
+							payload: []byte("test-payload-other"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/light/test-prefix_cars_1/light/config",
+							payload: []byte("test-payload-light"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/device/test-prefix_cars_3/config",
+							payload: []byte("test-payload-3"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/update/test-prefix_cars_1/software_update/config",
+							payload: []byte("test-payload-4"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/status",
+							payload: []byte("online"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+				tmCfg: tm.Config{IdleTimeout: 250 * time.Millisecond, Prefix: "test-prefix"},
+			},
+			want: []RetainedConfig{
+				{
+					Topic:     "test-discovery-prefix/binary_sensor/test-prefix_cars_1/plug/config",
+					VehicleId: "1",
+					Payload:   []byte("test-payload-1"),
+				},
+				{
+					Topic:     "test-discovery-prefix/device/test-prefix_cars_3/config",
+					VehicleId: "3",
+					Payload:   []byte("test-payload-3"),
+				},
+				{
+					Topic:     "test-discovery-prefix/sensor/test-prefix_cars_2/range/config",
+					VehicleId: "2",
+					Payload:   []byte("test-payload-2"),
+				},
+				{
+					Topic:     "test-discovery-prefix/update/test-prefix_cars_1/software_update/config",
+					VehicleId: "1",
+					Payload:   []byte("test-payload-4"),
+				},
+			},
+		},
+		{
+			name: "slow broker",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeMatching: true,
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/sensor/test-prefix_cars_1/range/config",
+							payload: []byte("test-payload-1"),
+						})
+						time.Sleep(300 * time.Millisecond)
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/sensor/test-prefix_cars_2/range/config",
+							payload: []byte("test-payload-2"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+				tmCfg: tm.Config{IdleTimeout: 500 * time.Millisecond, Prefix: "test-prefix"},
+			},
+			want: []RetainedConfig{
+				{
+					Topic:     "test-discovery-prefix/sensor/test-prefix_cars_1/range/config",
+					VehicleId: "1",
+					Payload:   []byte("test-payload-1"),
+				},
+				{
+					Topic:     "test-discovery-prefix/sensor/test-prefix_cars_2/range/config",
+					VehicleId: "2",
+					Payload:   []byte("test-payload-2"),
+				},
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("subscribe error")},
+					},
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+				tmCfg: tm.Config{IdleTimeout: 250 * time.Millisecond, Prefix: "test-prefix"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+			got, err := m.ListDiscovery(tt.args.ctx, tt.args.haCfg, tt.args.tmCfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.ListDiscovery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var topics []string
+			for _, a := range tt.fields.Client.subscribeArgs {
+				topics = append(topics, a.topic)
+			}
+			if want := []string{"test-discovery-prefix/+/+/+/config", "test-discovery-prefix/device/+/config"}; !reflect.DeepEqual(topics, want) {
+				t.Errorf("MQTT.ListDiscovery() topics = %v, want %v", topics, want)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MQTT.ListDiscovery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nebhale/teslamate-discovery/ha"
@@ -14,61 +17,127 @@ import (
 )
 
 func (m *MQTT) ListVehicles(ctx context.Context, tmCfg tm.Config) (map[string]ha.Device, error) {
+	if err := tmCfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	fmt.Println("Listing Vehicles")
 
 	topic := fmt.Sprintf("%s/#", tmCfg.Prefix)
 
-	in, err := m.Subscribe(ctx, topic)
+	in, err := m.Subscribe(ctx, topic, 0)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = m.Unsubscribe(ctx, topic) }()
 
-	vehicles := make(map[string]ha.Device)
-	r := regexp.MustCompile(fmt.Sprintf(`%s/cars/([\d]+)/([\w]+)`, tmCfg.Prefix))
+	vehicles := make(map[string]*tm.Vehicle)
+	r := VehicleTopicRegexp(tmCfg)
+
+	var deadline <-chan time.Time
+	if tmCfg.Timeout > 0 {
+		deadline = time.After(tmCfg.Timeout)
+	}
+
+	idle := time.NewTimer(tmCfg.IdleTimeout)
+	defer idle.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, nil
+			return nil, ctx.Err()
 
 		case msg := <-in:
+			idle.Reset(tmCfg.IdleTimeout)
+
 			s := r.FindStringSubmatch(msg.Topic())
 			if s == nil {
 				continue
 			}
 
-			switch s[2] {
-			case "display_name":
-				dev := vehicles[s[1]]
-				dev.Name = string(msg.Payload())
-				vehicles[s[1]] = dev
-			case "model":
-				dev := vehicles[s[1]]
-				dev.Model = fmt.Sprintf("Model %s%s", string(msg.Payload()), dev.Model)
-				vehicles[s[1]] = dev
-			case "trim_badging":
-				dev := vehicles[s[1]]
-				dev.Model = fmt.Sprintf("%s %s", dev.Model, string(msg.Payload()))
-				vehicles[s[1]] = dev
-			case "version":
-				dev := vehicles[s[1]]
-				dev.SoftwareVersion = string(msg.Payload())
-				vehicles[s[1]] = dev
+			v, ok := vehicles[s[1]]
+			if !ok {
+				v = &tm.Vehicle{}
+				vehicles[s[1]] = v
+			}
+			v.Set(s[2], string(msg.Payload()))
+
+		case <-idle.C:
+			err := CheckVehicles(vehicles, tmCfg)
+			if err == nil {
+				return Devices(vehicles, tmCfg), nil
 			}
 
-		case <-time.After(250 * time.Millisecond):
-			for id, dev := range vehicles {
-				if dev.Name == "" {
-					dev.Name = "Tesla"
-				}
-				dev.Identifiers = []string{fmt.Sprintf("%s/cars/%s", tmCfg.Prefix, id)}
-				dev.Manufacturer = "Tesla"
-				dev.SuggestedArea = "Garage"
-				vehicles[id] = dev
+			// without an overall deadline there is nothing more to wait for
+			if deadline == nil {
+				return nil, err
 			}
 
-			return vehicles, nil
+		case <-deadline:
+			if err := CheckVehicles(vehicles, tmCfg); err != nil {
+				return nil, fmt.Errorf("%w after %s", err, tmCfg.Timeout)
+			}
+
+			return Devices(vehicles, tmCfg), nil
 		}
 	}
 }
+
+func CheckVehicles(vehicles map[string]*tm.Vehicle, tmCfg tm.Config) error {
+	// vehicles that will be filtered out do not need to be complete, or count towards those expected, but one that only
+	// its name could include is waited for until the name is known
+	ids := make([]string, 0, len(vehicles))
+	pending := make(map[string]bool)
+	for id, v := range vehicles {
+		var ok bool
+		if v.DisplayName == "" {
+			ok, pending[id], _ = tmCfg.MatchVehicleId(id)
+		} else {
+			ok, _, _ = tmCfg.MatchVehicle(id, v.Device(tmCfg, id).Name)
+		}
+
+		if ok || pending[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	if len(ids) == 0 {
+		return fmt.Errorf("no vehicles found under %s/cars", tmCfg.Prefix)
+	}
+
+	var incomplete []string
+	for _, id := range ids {
+		missing := vehicles[id].Missing(tmCfg.RequiredMetadata)
+		if pending[id] && !slices.Contains(missing, "display_name") {
+			missing = append(missing, "display_name")
+		}
+
+		if len(missing) > 0 {
+			incomplete = append(incomplete, fmt.Sprintf("%s (missing %s)", id, strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(incomplete) > 0 {
+		return fmt.Errorf("incomplete vehicles found: %s", strings.Join(incomplete, "; "))
+	}
+
+	if tmCfg.ExpectedVehicles > 0 && len(ids) < tmCfg.ExpectedVehicles {
+		return fmt.Errorf("found %d vehicles, expected %d", len(ids), tmCfg.ExpectedVehicles)
+	}
+
+	return nil
+}
+
+func Devices(vehicles map[string]*tm.Vehicle, tmCfg tm.Config) map[string]ha.Device {
+	devices := make(map[string]ha.Device, len(vehicles))
+	for id, v := range vehicles {
+		devices[id] = v.Device(tmCfg, id)
+	}
+
+	return devices
+}
+
+func VehicleTopicRegexp(tmCfg tm.Config) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^%s/cars/([\d]+)/([\w]+)$`, regexp.QuoteMeta(tmCfg.Prefix)))
+}
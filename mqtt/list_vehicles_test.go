@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
 
@@ -86,8 +87,13 @@ func TestMQTT_ListVehicles(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx:   context.Background(),
-				tmCfg: tm.Config{Prefix: "test-prefix"},
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					IdleTimeout:      50 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: []string{"model", "version"},
+					Timeout:          time.Second,
+				},
 			},
 			want: map[string]ha.Device{
 				"1": {
@@ -116,6 +122,189 @@ func TestMQTT_ListVehicles(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "name arrives late",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/display_name",
+							payload: []byte("test-display-name-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/model",
+							payload: []byte("test-model-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/model",
+							payload: []byte("test-model-2"),
+						})
+
+						// the vehicle is only included by its name, which arrives after the first idle check
+						time.Sleep(100 * time.Millisecond)
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/display_name",
+							payload: []byte("test-display-name-2"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					IdleTimeout:      10 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: []string{"model"},
+					Timeout:          time.Second,
+					Vehicles:         []string{"test-display-name-*"},
+				},
+			},
+			want: map[string]ha.Device{
+				"1": {
+					Identifiers:   []string{"test-prefix/cars/1"},
+					Manufacturer:  "Tesla",
+					Model:         "Model test-model-1",
+					Name:          "test-display-name-1",
+					SuggestedArea: "Garage",
+				},
+				"2": {
+					Identifiers:   []string{"test-prefix/cars/2"},
+					Manufacturer:  "Tesla",
+					Model:         "Model test-model-2",
+					Name:          "test-display-name-2",
+					SuggestedArea: "Garage",
+				},
+			},
+		},
+		{
+			name: "incomplete",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/display_name",
+							payload: []byte("test-display-name-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/model",
+							payload: []byte("test-model-1"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					IdleTimeout:      10 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: tm.DefaultRequiredMetadata,
+					Timeout:          100 * time.Millisecond,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "excluded incomplete",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/model",
+							payload: []byte("test-model-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/display_name",
+							payload: []byte("test-display-name-2"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					ExcludeVehicles:  []string{"2"},
+					ExpectedVehicles: 1,
+					IdleTimeout:      10 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: []string{"model"},
+					Timeout:          100 * time.Millisecond,
+				},
+			},
+			want: map[string]ha.Device{
+				"1": {
+					Identifiers:   []string{"test-prefix/cars/1"},
+					Manufacturer:  "Tesla",
+					Model:         "Model test-model-1",
+					Name:          "Tesla",
+					SuggestedArea: "Garage",
+				},
+				"2": {
+					Identifiers:   []string{"test-prefix/cars/2"},
+					Manufacturer:  "Tesla",
+					Name:          "test-display-name-2",
+					SuggestedArea: "Garage",
+				},
+			},
+		},
+		{
+			name: "expected",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/display_name",
+							payload: []byte("test-display-name-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/model",
+							payload: []byte("test-model-1"),
+						})
+					},
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					ExpectedVehicles: 2,
+					IdleTimeout:      10 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: []string{"model"},
+					Timeout:          100 * time.Millisecond,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no vehicles",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					IdleTimeout: 10 * time.Millisecond,
+					Prefix:      "test-prefix",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid required metadata",
+			args: args{
+				ctx: context.Background(),
+				tmCfg: tm.Config{
+					Prefix:           "test-prefix",
+					RequiredMetadata: []string{"speed"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "error",
 			fields: fields{
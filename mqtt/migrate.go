@@ -0,0 +1,49 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+// MigrateDiscoveryPayload tells Home Assistant that the entities configured by a discovery topic are about to be
+// configured by another, so that they keep their registry entries rather than being removed and discovered again.
+const MigrateDiscoveryPayload = `{"migrate_discovery":true}`
+
+// MigratingTopics returns the retained configuration of the given vehicles that was published in the other discovery
+// mode.
+func MigratingTopics(retained []RetainedConfig, vehicles map[string]ha.Device, haCfg ha.Config) []string {
+	device := fmt.Sprintf("%s/device/", haCfg.DiscoveryPrefix)
+
+	var topics []string
+	for _, r := range retained {
+		if _, ok := vehicles[r.VehicleId]; !ok {
+			continue
+		}
+
+		if strings.HasPrefix(r.Topic, device) != (haCfg.DiscoveryMode == ha.DeviceDiscovery) {
+			topics = append(topics, r.Topic)
+		}
+	}
+
+	return topics
+}
+
+// Migrate marks configuration for migration, which must happen before the replacement configuration is published.
+// The marked configuration is then removed once the replacement has been published.
+func (m *MQTT) Migrate(ctx context.Context, topics ...string) error {
+	fmt.Println("Migrating Discovery Configurations")
+
+	messages := make([]Message, 0, len(topics))
+	for _, topic := range topics {
+		messages = append(messages, Message{Topic: topic, Payload: []byte(MigrateDiscoveryPayload)})
+	}
+
+	// the marker replaces the retained configuration so that it is not rediscovered before it is removed
+	return m.PublishWindow(ctx, true, messages...)
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestMigratingTopics(t *testing.T) {
+	retained := []RetainedConfig{
+		{Topic: "test-discovery-prefix/device/test-prefix_cars_1/config", VehicleId: "1"},
+		{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/range/config", VehicleId: "1"},
+		{Topic: "test-discovery-prefix/sensor/test-prefix_cars_2/range/config", VehicleId: "2"},
+	}
+	vehicles := map[string]ha.Device{
+		"1": {Identifiers: []string{"test-prefix/cars/1"}},
+	}
+
+	tests := []struct {
+		name string
+		mode ha.DiscoveryMode
+		want []string
+	}{
+		{
+			name: "to device",
+			mode: ha.DeviceDiscovery,
+			want: []string{"test-discovery-prefix/sensor/test-prefix_cars_1/range/config"},
+		},
+		{
+			name: "to entity",
+			mode: ha.EntityDiscovery,
+			want: []string{"test-discovery-prefix/device/test-prefix_cars_1/config"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			haCfg := ha.Config{DiscoveryMode: tt.mode, DiscoveryPrefix: "test-discovery-prefix"}
+
+			if got := MigratingTopics(retained, vehicles, haCfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MigratingTopics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMQTT_Migrate(t *testing.T) {
+	c := &stubPubSub{publishTokens: []paho.Token{&stubToken{}}}
+	m := &MQTT{Client: c, QoS: 1}
+
+	if err := m.Migrate(context.Background(), "test-topic"); err != nil {
+		t.Fatalf("MQTT.Migrate() error = %v", err)
+	}
+
+	want := []publishArgs{{topic: "test-topic", qos: 1, retained: true, payload: []byte(MigrateDiscoveryPayload)}}
+	if !reflect.DeepEqual(c.publishArgs, want) {
+		t.Errorf("MQTT.Migrate() published = %v, want %v", c.publishArgs, want)
+	}
+}
@@ -5,8 +5,13 @@ package mqtt
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
 
@@ -20,99 +25,284 @@ type PubSub interface {
 }
 
 type MQTT struct {
-	Client PubSub
+	Client       PubSub
+	Disconnected <-chan struct{}
+	QoS          byte
+	NoRetain     bool
+	InFlight     int
+	AckTimeout   time.Duration
 }
 
 func NewMQTT(ctx context.Context, config Config) (*MQTT, error) {
-	uri := BrokerURI(config)
-	fmt.Printf("Connecting to %s\n", uri)
+	config, err := ReadCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsCfg, err := NewTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	urls, err := BrokerURLs(config)
+	if err != nil {
+		return nil, err
+	}
+	redactor := NewRedactor(config, urls)
 
-	c := paho.NewClient(paho.NewClientOptions().
-		AddBroker(uri).
-		SetClientID(fmt.Sprintf("teslamate-discovery-%s", RandomString(12))).
+	clientID := NewClientID(config)
+	fmt.Printf("Connecting to %s as %s\n", RedactURLs(urls), clientID)
+
+	// subscriptions do not survive a reconnection with a clean session, so they are restored by the client
+	r := &ResubscribingPubSub{}
+	session := r.Session(clientID)
+
+	// the broker marks the application offline if the connection is lost, so it is marked online on every connection.
+	// closing the connection does not publish the will, so the client marks the application offline itself before
+	// disconnecting once ctx is done, and closes Disconnected when it has.
+	if config.AvailabilityTopic != "" {
+		session.Will = &Message{Topic: config.AvailabilityTopic, Payload: []byte(StatusOffline)}
+
+		reconnected := session.Reconnected
+		session.Reconnected = func() {
+			reconnected()
+
+			t := r.Publish(config.AvailabilityTopic, byte(config.QoS), true, StatusOnline)
+			<-t.Done()
+			if err := t.Error(); err != nil {
+				fmt.Printf("Unable to publish availability to %s: %s\n", config.AvailabilityTopic, err)
+			}
+		}
+	}
+
+	if config.Protocol == 5 {
+		r.Client, err = NewMQTT5PubSub(ctx, config, urls, tlsCfg, session)
+	} else {
+		r.Client, err = NewMQTT3PubSub(ctx, config, urls, tlsCfg, session)
+	}
+	if err != nil {
+		return nil, redactor.Error(TLSError(err))
+	}
+
+	m := &MQTT{
+		Client:       r,
+		Disconnected: session.Disconnected,
+		QoS:          byte(config.QoS),
+		NoRetain:     config.NoRetain,
+		InFlight:     config.InFlight,
+		AckTimeout:   config.AckTimeout,
+	}
+
+	if config.AvailabilityTopic != "" {
+		fmt.Printf("Publishing availability to %s\n", config.AvailabilityTopic)
+
+		if err := m.PublishWindow(ctx, true, Message{Topic: config.AvailabilityTopic, Payload: []byte(StatusOnline)}); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func NewMQTT3PubSub(ctx context.Context, config Config, urls []*url.URL, tlsCfg *tls.Config,
+	session Session) (PubSub, error) {
+
+	headers, err := NewWebSocketHeaders(config.WebSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	wsOpts, err := NewWebSocketOptions(config.WebSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu           sync.Mutex
+		broker       *url.URL
+		reconnecting bool
+		redactor     = NewRedactor(config, urls)
+	)
+
+	opts := paho.NewClientOptions().
+		SetClientID(session.ClientID).
+		SetCleanSession(config.CleanSession).
+		SetDefaultPublishHandler(session.Queued).
+		SetConnectionNotificationHandler(func(_ paho.Client, n paho.ConnectionNotification) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch n := n.(type) {
+			case paho.ConnectionNotificationBroker:
+				broker = n.Broker
+			case paho.ConnectionNotificationBrokerFailed:
+				if len(urls) > 1 || reconnecting {
+					fmt.Printf("Unable to connect to %s: %s\n", RedactURL(n.Broker.String()), redactor.Error(n.Reason))
+				}
+			case paho.ConnectionNotificationLost:
+				fmt.Printf("Disconnected from %s%s\n", RedactURL(broker.String()), DisconnectReason(redactor.Error(n.Reason)))
+			case paho.ConnectionNotificationConnecting:
+				if n.IsReconnect {
+					reconnecting = true
+					fmt.Printf("Reconnecting to %s\n", RedactURLs(urls))
+				}
+			case paho.ConnectionNotificationConnected:
+				if reconnecting {
+					reconnecting = false
+					fmt.Printf("Reconnected to %s\n", RedactURL(broker.String()))
+					go session.Reconnected()
+				}
+			}
+		}).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(max(config.ReconnectMax, InitialReconnectInterval)).
+		SetHTTPHeaders(headers).
 		SetOrderMatters(false).
+		SetTLSConfig(tlsCfg).
 		SetUsername(config.Username).
-		SetPassword(config.Password))
+		SetPassword(config.Password).
+		SetWebsocketOptions(wsOpts)
+
+	// brokers are tried in order, each using the credentials in its url in preference to the configured ones
+	for _, u := range urls {
+		opts.AddBroker(u.String())
+	}
+
+	if session.Will != nil {
+		opts.SetBinaryWill(session.Will.Topic, session.Will.Payload, byte(config.QoS), true)
+	}
+
+	c := paho.NewClient(opts)
 
 	t := c.Connect()
 	select {
 	case <-ctx.Done():
-		return nil, nil
+		return nil, ctx.Err()
 	case <-t.Done():
 		if err := t.Error(); err != nil {
 			return nil, err
 		}
 	}
 
+	ct, ok := t.(*paho.ConnectToken)
+
+	mu.Lock()
+	fmt.Printf("Connected to %s%s\n", RedactURL(broker.String()), SessionResumed(ok && ct.SessionPresent()))
+	mu.Unlock()
+
 	go func() {
+		defer close(session.Disconnected)
 		<-ctx.Done()
+
+		if session.Will != nil {
+			c.Publish(session.Will.Topic, byte(config.QoS), true, session.Will.Payload).WaitTimeout(500 * time.Millisecond)
+		}
 		c.Disconnect(500)
 	}()
 
-	return &MQTT{Client: c}, nil
+	return c, nil
 }
 
 func (m *MQTT) Publish(ctx context.Context, discoveryPrefix string, v ...interface{}) error {
-	for _, v := range v {
-		var topic string
-
-		switch v := v.(type) {
-		case ha.BinarySensor:
-			topic = fmt.Sprintf("%s/binary_sensor/%s/config", discoveryPrefix, v.UniqueId)
-		case ha.DeviceTracker:
-			topic = fmt.Sprintf("%s/device_tracker/%s/config", discoveryPrefix, v.UniqueId)
-		case ha.Sensor:
-			topic = fmt.Sprintf("%s/sensor/%s/config", discoveryPrefix, v.UniqueId)
-		default:
-			return fmt.Errorf("unexpected message type: %T", v)
-		}
-
-		fmt.Printf("  %s\n", topic)
+	messages := make([]Message, 0, len(v))
 
-		payload, err := json.Marshal(v)
+	for _, v := range v {
+		msg, err := DiscoveryMessage(discoveryPrefix, v)
 		if err != nil {
 			return err
 		}
+		messages = append(messages, msg)
+	}
+
+	return m.PublishMessages(ctx, messages...)
+}
+
+func (m *MQTT) PublishMessages(ctx context.Context, messages ...Message) error {
+	return m.PublishWindow(ctx, !m.NoRetain, messages...)
+}
+
+func (m *MQTT) Clear(ctx context.Context, topics ...string) error {
+	messages := make([]Message, 0, len(topics))
+	for _, topic := range topics {
+		messages = append(messages, Message{Topic: topic, Payload: []byte{}})
+	}
+
+	// an empty payload only removes configuration from the broker when it is retained
+	return m.PublishWindow(ctx, true, messages...)
+}
+
+// Subscribe delivers the messages published to topic.  Only a subscription at qos 1 or 2 has messages queued by a
+// persistent session while disconnected, so state that is worthless once stale should be subscribed to at qos 0.
+func (m *MQTT) Subscribe(ctx context.Context, topic string, qos byte) (<-chan paho.Message, error) {
+	// messages queued by a resumed session are delivered before the subscription is made, so there must be room for them
+	ch := make(chan paho.Message, MaxQueuedMessages)
+
+	for attempt := 1; ; attempt++ {
+		t := m.Client.Subscribe(topic, qos, func(c paho.Client, m paho.Message) {
+			ch <- m
+		})
 
-		t := m.Client.Publish(topic, 0, true, payload)
 		select {
 		case <-ctx.Done():
-			return nil
+			return nil, ctx.Err()
 		case <-t.Done():
-			if err := t.Error(); err != nil {
-				return err
+		}
+
+		// a resumed session may still be delivering queued messages under the identifier chosen for the subscription
+		err := SubscribeError(t)
+		var rce *ReasonCodeError
+		if !errors.As(err, &rce) || rce.Code != PacketIdentifierInUse || attempt == SubscribeAttempts {
+			if err != nil {
+				return nil, err
 			}
+			return ch, nil
 		}
-	}
 
-	return nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+		}
+	}
 }
 
-func (m *MQTT) Subscribe(ctx context.Context, topic string) (<-chan paho.Message, error) {
-	ch := make(chan paho.Message)
+const (
+	PacketIdentifierInUse = 0x91
+	SubscribeAttempts     = 5
+)
 
-	t := m.Client.Subscribe(topic, 0, func(c paho.Client, m paho.Message) {
-		ch <- m
-	})
+func SubscribeError(t paho.Token) error {
+	if err := t.Error(); err != nil {
+		return err
+	}
 
-	select {
-	case <-ctx.Done():
-		return nil, nil
-	case <-t.Done():
-		if err := t.Error(); err != nil {
-			return nil, err
+	// MQTT 3 reports a refused subscription in the SUBACK rather than as an error
+	if st, ok := t.(*paho.SubscribeToken); ok {
+		for topic, code := range st.Result() {
+			if code >= 0x80 {
+				return &ReasonCodeError{Operation: fmt.Sprintf("subscribe to %s", topic), Code: code}
+			}
 		}
 	}
 
-	return ch, nil
+	return nil
 }
 
 func (m *MQTT) Unsubscribe(ctx context.Context, topic string) error {
+	// a watch ended by shutting down keeps its subscription, so that a persistent session queues messages for the next run
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	t := m.Client.Unsubscribe(topic)
 
 	select {
 	case <-ctx.Done():
-		return nil
+		return ctx.Err()
 	case <-t.Done():
 		if err := t.Error(); err != nil {
 			return err
@@ -122,6 +312,42 @@ func (m *MQTT) Unsubscribe(ctx context.Context, topic string) error {
 	return nil
 }
 
+func DiscoveryTopic(discoveryPrefix string, v interface{}) (string, error) {
+	e, ok := v.(ha.Entity)
+	if !ok {
+		return "", fmt.Errorf("unexpected message type: %T", v)
+	}
+
+	return fmt.Sprintf("%s/%s/%s/config", discoveryPrefix, e.Component(), e.Common().UniqueId), nil
+}
+
+func NewClientID(config Config) string {
+	id := config.ClientID
+	if id == "" {
+		id = DefaultClientID
+	}
+
+	if config.ClientIDSuffix {
+		id = fmt.Sprintf("%s-%s", id, RandomString(12))
+	}
+
+	return id
+}
+
+func SessionResumed(present bool) string {
+	if !present {
+		return ""
+	}
+
+	return ", resuming session"
+}
+
 func BrokerURI(config Config) string {
-	return fmt.Sprintf("%s://%s:%d", config.Scheme, config.Host, config.Port)
+	uri := fmt.Sprintf("%s://%s:%d", config.Scheme, config.Host, config.Port)
+
+	if IsWebSocket(config.Scheme) && config.WebSocket.Path != "" {
+		uri = fmt.Sprintf("%s/%s", uri, strings.TrimPrefix(config.WebSocket.Path, "/"))
+	}
+
+	return uri
 }
@@ -0,0 +1,539 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	paho5 "github.com/eclipse/paho.golang/paho"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gorilla/websocket"
+)
+
+type PubSub5 interface {
+	Publish(ctx context.Context, p *paho5.Publish) (*paho5.PublishResponse, error)
+	Subscribe(ctx context.Context, s *paho5.Subscribe) (*paho5.Suback, error)
+	Unsubscribe(ctx context.Context, u *paho5.Unsubscribe) (*paho5.Unsuback, error)
+}
+
+// MQTT5PubSub adapts an MQTT 5 client to PubSub so that the rest of the package is unaware of the protocol version.
+type MQTT5PubSub struct {
+	Client         PubSub5
+	MessageExpiry  time.Duration
+	UserProperties paho5.UserProperties
+	Queued         paho.MessageHandler
+
+	mu       sync.RWMutex
+	handlers map[string]paho.MessageHandler
+}
+
+func NewMQTT5PubSub(ctx context.Context, config Config, urls []*url.URL, tlsCfg *tls.Config,
+	session Session) (*MQTT5PubSub, error) {
+
+	headers, err := NewWebSocketHeaders(config.WebSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	wsOpts, err := NewWebSocketOptions(config.WebSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	props, err := NewUserProperties(config.UserProperties)
+	if err != nil {
+		return nil, err
+	}
+
+	// autopaho includes the url in its errors, so credentials are removed and supplied per connection instead
+	servers := make([]*url.URL, 0, len(urls))
+	credentials := make(map[*url.URL][2]string, len(urls))
+	for _, u := range urls {
+		s := *u
+		s.User = nil
+		servers = append(servers, &s)
+
+		username, password := BrokerCredentials(config, u)
+		credentials[&s] = [2]string{username, password}
+	}
+
+	var (
+		mu          sync.Mutex
+		broker      *url.URL
+		failures    int
+		connections int
+		resumed     bool
+		lost        error
+		redactor    = NewRedactor(config, urls)
+	)
+
+	p := &MQTT5PubSub{
+		MessageExpiry:  config.MessageExpiry,
+		UserProperties: props,
+		Queued:         session.Queued,
+	}
+
+	// autopaho retries failed connections forever, so the first round of failures is captured to fail fast like MQTT 3
+	errs := make(chan error, 1)
+
+	var will *paho5.WillMessage
+	if session.Will != nil {
+		will = &paho5.WillMessage{Retain: true, QoS: byte(config.QoS), Topic: session.Will.Topic, Payload: session.Will.Payload}
+	}
+
+	// the connection outlives ctx long enough to send a DISCONNECT once ctx is done
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	cm, err := autopaho.NewConnection(cctx, autopaho.ClientConfig{
+		WillMessage:                   will,
+		ServerUrls:                    servers,
+		TlsCfg:                        tlsCfg,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: config.CleanSession,
+		SessionExpiryInterval:         SessionExpiryInterval(config),
+		ConnectTimeout:                30 * time.Second,
+		ReconnectBackoff:              NewReconnectBackoff(config.ReconnectMax),
+		ConnectPacketBuilder: func(cp *paho5.Connect, u *url.URL) (*paho5.Connect, error) {
+			mu.Lock()
+			broker = u
+			mu.Unlock()
+
+			c := credentials[u]
+			cp.UsernameFlag, cp.Username = c[0] != "", c[0]
+			cp.PasswordFlag, cp.Password = c[1] != "", []byte(c[1])
+			return cp, nil
+		},
+		WebSocketCfg: &autopaho.WebSocketConfig{
+			Dialer: func(_ *url.URL, tlsCfg *tls.Config) *websocket.Dialer {
+				d := *websocket.DefaultDialer
+				d.Subprotocols = []string{"mqtt"}
+				d.TLSClientConfig = tlsCfg
+				if wsOpts.Proxy != nil {
+					d.Proxy = wsOpts.Proxy
+				}
+				return &d
+			},
+			Header: func(*url.URL, *tls.Config) http.Header {
+				return headers
+			},
+		},
+		OnConnectError: func(err error) {
+			var ce *autopaho.ConnackError
+			if errors.As(err, &ce) {
+				err = &ReasonCodeError{Operation: "connect", Code: ce.ReasonCode, Reason: ce.Reason}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(urls) > 1 || connections > 0 {
+				cause := err
+				if u := errors.Unwrap(err); u != nil && ce == nil {
+					cause = u
+				}
+				fmt.Printf("Unable to connect to %s: %s\n", broker, redactor.Error(cause))
+			}
+
+			failures++
+			if failures == len(urls) {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+		},
+		OnConnectionUp: func(_ *autopaho.ConnectionManager, ca *paho5.Connack) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			connections++
+			if connections == 1 {
+				resumed = ca.SessionPresent
+			} else {
+				fmt.Printf("Reconnected to %s\n", broker)
+				go session.Reconnected()
+			}
+		},
+		OnConnectionDown: func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			fmt.Printf("Disconnected from %s%s\n", broker, DisconnectReason(redactor.Error(lost)))
+			fmt.Printf("Reconnecting to %s\n", RedactURLs(urls))
+			lost = nil
+			return true
+		},
+		ClientConfig: paho5.ClientConfig{
+			ClientID:          session.ClientID,
+			OnPublishReceived: []func(paho5.PublishReceived) (bool, error){p.Receive},
+			OnClientError: func(err error) {
+				mu.Lock()
+				lost = err
+				mu.Unlock()
+			},
+			OnServerDisconnect: func(d *paho5.Disconnect) {
+				e := &ReasonCodeError{Operation: "connection", Code: d.ReasonCode}
+				if d.Properties != nil {
+					e.Reason = d.Properties.ReasonString
+				}
+
+				mu.Lock()
+				lost = e
+				mu.Unlock()
+			},
+		},
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	up := make(chan error, 1)
+	go func() { up <- cm.AwaitConnection(cctx) }()
+
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	case err := <-errs:
+		cancel()
+		return nil, err
+	case err := <-up:
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	mu.Lock()
+	fmt.Printf("Connected to %s%s\n", broker, SessionResumed(resumed))
+	mu.Unlock()
+
+	go func() {
+		defer close(session.Disconnected)
+		defer cancel()
+
+		<-ctx.Done()
+		dctx, dcancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer dcancel()
+
+		if session.Will != nil {
+			_, _ = cm.Publish(dctx, &paho5.Publish{Topic: session.Will.Topic, QoS: byte(config.QoS), Retain: true, Payload: session.Will.Payload})
+		}
+		_ = cm.Disconnect(dctx)
+	}()
+
+	p.Client = cm
+	return p, nil
+}
+
+func (p *MQTT5PubSub) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	b, err := PayloadBytes(payload)
+	if err != nil {
+		return newToken(func() error { return err })
+	}
+
+	pb := &paho5.Publish{
+		Topic:      topic,
+		QoS:        qos,
+		Retain:     retained,
+		Payload:    b,
+		Properties: &paho5.PublishProperties{User: p.UserProperties},
+	}
+
+	// a retained message is the current state of its topic and must not disappear from the broker
+	if !retained && p.MessageExpiry > 0 {
+		e := uint32((p.MessageExpiry + time.Second - 1) / time.Second)
+		pb.Properties.MessageExpiry = &e
+	}
+
+	return newToken(func() error {
+		r, err := p.Client.Publish(context.Background(), pb)
+		if r != nil && r.ReasonCode >= 0x80 {
+			e := &ReasonCodeError{Operation: fmt.Sprintf("publish to %s", topic), Code: r.ReasonCode}
+			if r.Properties != nil {
+				e.Reason = r.Properties.ReasonString
+			}
+			return e
+		}
+		return err
+	})
+}
+
+func (p *MQTT5PubSub) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	// the handler is registered first so that retained messages sent before the SUBACK are not lost
+	p.mu.Lock()
+	if p.handlers == nil {
+		p.handlers = make(map[string]paho.MessageHandler)
+	}
+	p.handlers[topic] = callback
+	p.mu.Unlock()
+
+	return newToken(func() error {
+		r, err := p.Client.Subscribe(context.Background(), &paho5.Subscribe{
+			Properties:    &paho5.SubscribeProperties{User: p.UserProperties},
+			Subscriptions: []paho5.SubscribeOptions{{Topic: topic, QoS: qos}},
+		})
+		if r != nil && len(r.Reasons) > 0 && r.Reasons[0] >= 0x80 {
+			e := &ReasonCodeError{Operation: fmt.Sprintf("subscribe to %s", topic), Code: r.Reasons[0]}
+			if r.Properties != nil {
+				e.Reason = r.Properties.ReasonString
+			}
+			err = e
+		}
+
+		if err != nil {
+			p.mu.Lock()
+			delete(p.handlers, topic)
+			p.mu.Unlock()
+		}
+
+		return err
+	})
+}
+
+func (p *MQTT5PubSub) Unsubscribe(topics ...string) paho.Token {
+	p.mu.Lock()
+	for _, t := range topics {
+		delete(p.handlers, t)
+	}
+	p.mu.Unlock()
+
+	return newToken(func() error {
+		r, err := p.Client.Unsubscribe(context.Background(), &paho5.Unsubscribe{
+			Topics:     topics,
+			Properties: &paho5.UnsubscribeProperties{User: p.UserProperties},
+		})
+		if r != nil {
+			for i, c := range r.Reasons {
+				if c < 0x80 || i >= len(topics) {
+					continue
+				}
+
+				e := &ReasonCodeError{Operation: fmt.Sprintf("unsubscribe from %s", topics[i]), Code: c}
+				if r.Properties != nil {
+					e.Reason = r.Properties.ReasonString
+				}
+				return e
+			}
+		}
+		return err
+	})
+}
+
+func (p *MQTT5PubSub) Receive(r paho5.PublishReceived) (bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	handled := false
+	for filter, h := range p.handlers {
+		if MatchTopic(filter, r.Packet.Topic) {
+			// handlers block until their message is consumed, matching MQTT 3 with order not mattering
+			go h(nil, message{r.Packet})
+			handled = true
+		}
+	}
+
+	if !handled && p.Queued != nil {
+		go p.Queued(nil, message{r.Packet})
+		handled = true
+	}
+
+	return handled, nil
+}
+
+// SessionExpiryInterval keeps a persistent session on the broker between connections, while a clean session ends with
+// its connection.
+func SessionExpiryInterval(config Config) uint32 {
+	if config.CleanSession {
+		return 0
+	}
+
+	return uint32(min(config.SessionExpiry/time.Second, math.MaxUint32))
+}
+
+func MatchTopic(filter string, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+
+	// wildcards in the first level do not match topics reserved by the broker
+	if strings.HasPrefix(topic, "$") && (f[0] == "+" || f[0] == "#") {
+		return false
+	}
+
+	for i, s := range f {
+		switch {
+		case s == "#":
+			return true
+		case i >= len(t):
+			return false
+		case s != "+" && s != t[i]:
+			return false
+		}
+	}
+
+	return len(f) == len(t)
+}
+
+func NewUserProperties(properties []string) (paho5.UserProperties, error) {
+	var u paho5.UserProperties
+
+	for _, s := range properties {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("mqtt user property %q must be in the form name=value", s)
+		}
+		u.Add(k, v)
+	}
+
+	return u, nil
+}
+
+func PayloadBytes(payload interface{}) ([]byte, error) {
+	switch p := payload.(type) {
+	case []byte:
+		return p, nil
+	case string:
+		return []byte(p), nil
+	case bytes.Buffer:
+		return p.Bytes(), nil
+	case *bytes.Buffer:
+		return p.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown payload type: %T", payload)
+	}
+}
+
+type ReasonCodeError struct {
+	Operation string
+	Code      byte
+	Reason    string
+}
+
+func (e *ReasonCodeError) Error() string {
+	s := fmt.Sprintf("%s failed with reason code 0x%02x", e.Operation, e.Code)
+
+	if n, ok := ReasonCodeNames[e.Code]; ok {
+		s = fmt.Sprintf("%s (%s)", s, n)
+	}
+	if e.Reason != "" {
+		s = fmt.Sprintf("%s: %s", s, e.Reason)
+	}
+
+	return s
+}
+
+var ReasonCodeNames = map[byte]string{
+	0x80: "unspecified error",
+	0x81: "malformed packet",
+	0x82: "protocol error",
+	0x83: "implementation specific error",
+	0x84: "unsupported protocol version",
+	0x85: "client identifier not valid",
+	0x86: "bad user name or password",
+	0x87: "not authorized",
+	0x88: "server unavailable",
+	0x89: "server busy",
+	0x8a: "banned",
+	0x8c: "bad authentication method",
+	0x8f: "topic filter invalid",
+	0x90: "topic name invalid",
+	0x91: "packet identifier in use",
+	0x95: "packet too large",
+	0x97: "quota exceeded",
+	0x99: "payload format invalid",
+	0x9a: "retain not supported",
+	0x9b: "qos not supported",
+	0x9c: "use another server",
+	0x9d: "server moved",
+	0x9e: "shared subscriptions not supported",
+	0x9f: "connection rate exceeded",
+	0xa1: "subscription identifiers not supported",
+	0xa2: "wildcard subscriptions not supported",
+}
+
+type message struct {
+	*paho5.Publish
+}
+
+func (m message) Duplicate() bool {
+	return m.Publish.Duplicate()
+}
+
+func (m message) Qos() byte {
+	return m.QoS
+}
+
+func (m message) Retained() bool {
+	return m.Retain
+}
+
+func (m message) Topic() string {
+	return m.Publish.Topic
+}
+
+func (m message) MessageID() uint16 {
+	return m.PacketID
+}
+
+func (m message) Payload() []byte {
+	return m.Publish.Payload
+}
+
+// Ack is a no-op because autopaho acknowledges messages once they have been handled.
+func (m message) Ack() {}
+
+type token struct {
+	done chan struct{}
+	err  error
+}
+
+func newToken(fn func() error) *token {
+	t := &token{done: make(chan struct{})}
+
+	go func() {
+		t.err = fn()
+		close(t.done)
+	}()
+
+	return t
+}
+
+func (t *token) Wait() bool {
+	<-t.done
+	return true
+}
+
+func (t *token) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func (t *token) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *token) Error() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
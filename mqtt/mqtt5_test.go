@@ -0,0 +1,450 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	paho5 "github.com/eclipse/paho.golang/paho"
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestMQTT5PubSub_Publish(t *testing.T) {
+	props := paho5.UserProperties{{Key: "publisher", Value: "teslamate-discovery"}}
+
+	type args struct {
+		retained bool
+		payload  interface{}
+	}
+	tests := []struct {
+		name        string
+		client      stubPubSub5
+		expiry      time.Duration
+		args        args
+		wantExpiry  *uint32
+		wantPayload []byte
+		wantCode    byte
+		wantErr     bool
+	}{
+		{
+			name:        "retained",
+			expiry:      time.Minute,
+			args:        args{retained: true, payload: []byte("test-payload")},
+			wantPayload: []byte("test-payload"),
+		},
+		{
+			name:        "not retained",
+			expiry:      1500 * time.Millisecond,
+			args:        args{payload: "test-payload"},
+			wantExpiry:  new(uint32(2)),
+			wantPayload: []byte("test-payload"),
+		},
+		{
+			name:        "no expiry",
+			args:        args{payload: "test-payload"},
+			wantPayload: []byte("test-payload"),
+		},
+		{
+			name: "reason code",
+			client: stubPubSub5{
+				publishResponse: &paho5.PublishResponse{
+					ReasonCode: 0x87,
+					Properties: &paho5.PublishResponseProperties{ReasonString: "test-reason"},
+				},
+				publishErr: fmt.Errorf("error publishing"),
+			},
+			args:        args{payload: []byte("test-payload")},
+			wantPayload: []byte("test-payload"),
+			wantCode:    0x87,
+			wantErr:     true,
+		},
+		{
+			name:    "unknown payload",
+			args:    args{payload: 42},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &MQTT5PubSub{
+				Client:         &tt.client,
+				MessageExpiry:  tt.expiry,
+				UserProperties: props,
+			}
+
+			tok := p.Publish("test-topic", 1, tt.args.retained, tt.args.payload)
+			<-tok.Done()
+
+			err := tok.Error()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT5PubSub.Publish() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var rce *ReasonCodeError
+			if tt.wantCode != 0 && (!errors.As(err, &rce) || rce.Code != tt.wantCode) {
+				t.Errorf("MQTT5PubSub.Publish() error = %v, want reason code %#x", err, tt.wantCode)
+			}
+
+			if tt.wantPayload == nil {
+				return
+			}
+
+			got := tt.client.publishArgs[0]
+			if !reflect.DeepEqual(got.Payload, tt.wantPayload) {
+				t.Errorf("MQTT5PubSub.Publish() payload = %s, want %s", got.Payload, tt.wantPayload)
+			}
+			if got.Retain != tt.args.retained || got.QoS != 1 || got.Topic != "test-topic" {
+				t.Errorf("MQTT5PubSub.Publish() = %v, want retained %t, qos 1, topic test-topic", got, tt.args.retained)
+			}
+			if !reflect.DeepEqual(got.Properties.MessageExpiry, tt.wantExpiry) {
+				t.Errorf("MQTT5PubSub.Publish() expiry = %v, want %v", got.Properties.MessageExpiry, tt.wantExpiry)
+			}
+			if !reflect.DeepEqual(got.Properties.User, props) {
+				t.Errorf("MQTT5PubSub.Publish() user properties = %v, want %v", got.Properties.User, props)
+			}
+		})
+	}
+}
+
+func TestMQTT5PubSub_Subscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    stubPubSub5
+		topic     string
+		want      []string
+		wantCode  byte
+		wantErr   bool
+		wantRoute bool
+	}{
+		{
+			name:      "wildcard",
+			client:    stubPubSub5{subscribeResponse: &paho5.Suback{Reasons: []byte{0x00}}},
+			topic:     "test-prefix/+/test-leaf",
+			want:      []string{"test-prefix/1/test-leaf"},
+			wantRoute: true,
+		},
+		{
+			name: "reason code",
+			client: stubPubSub5{
+				subscribeResponse: &paho5.Suback{Reasons: []byte{0x87}},
+				subscribeErr:      fmt.Errorf("failed to subscribe to topic"),
+			},
+			topic:    "test-prefix/#",
+			wantCode: 0x87,
+			wantErr:  true,
+		},
+		{
+			name:    "error",
+			client:  stubPubSub5{subscribeErr: fmt.Errorf("test-error")},
+			topic:   "test-prefix/#",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &MQTT5PubSub{Client: &tt.client}
+
+			ch := make(chan paho.Message, 4)
+			tok := p.Subscribe(tt.topic, 0, func(_ paho.Client, m paho.Message) { ch <- m })
+			<-tok.Done()
+
+			err := tok.Error()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT5PubSub.Subscribe() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var rce *ReasonCodeError
+			if tt.wantCode != 0 && (!errors.As(err, &rce) || rce.Code != tt.wantCode) {
+				t.Errorf("MQTT5PubSub.Subscribe() error = %v, want reason code %#x", err, tt.wantCode)
+			}
+
+			if got := tt.client.subscribeArgs[0].Subscriptions[0].Topic; got != tt.topic {
+				t.Errorf("MQTT5PubSub.Subscribe() topic = %v, want %v", got, tt.topic)
+			}
+
+			for _, topic := range []string{"test-prefix/1/test-leaf", "test-prefix/1/other-leaf"} {
+				handled, _ := p.Receive(paho5.PublishReceived{
+					Packet: &paho5.Publish{Topic: topic, Retain: true, Payload: []byte("test-payload")},
+				})
+				if handled != (tt.wantRoute && topic == "test-prefix/1/test-leaf") {
+					t.Errorf("MQTT5PubSub.Receive(%s) = %t", topic, handled)
+				}
+			}
+
+			var got []string
+			for range tt.want {
+				m := <-ch
+				if !m.Retained() || string(m.Payload()) != "test-payload" {
+					t.Errorf("MQTT5PubSub.Subscribe() message = %v", m)
+				}
+				got = append(got, m.Topic())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MQTT5PubSub.Subscribe() messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMQTT5PubSub_Unsubscribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   stubPubSub5
+		wantCode byte
+		wantErr  bool
+	}{
+		{
+			name:   "unsubscribed",
+			client: stubPubSub5{unsubscribeResp: &paho5.Unsuback{Reasons: []byte{0x00, 0x11}}},
+		},
+		{
+			name: "reason code",
+			client: stubPubSub5{
+				unsubscribeResp: &paho5.Unsuback{Reasons: []byte{0x00, 0x8f}},
+				unsubscribeErr:  fmt.Errorf("failed to unsubscribe from topic"),
+			},
+			wantCode: 0x8f,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &MQTT5PubSub{Client: &tt.client}
+
+			tok := p.Unsubscribe("test-topic-1", "test-topic-2")
+			<-tok.Done()
+
+			err := tok.Error()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT5PubSub.Unsubscribe() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var rce *ReasonCodeError
+			if tt.wantCode != 0 && (!errors.As(err, &rce) || rce.Code != tt.wantCode || rce.Operation != "unsubscribe from test-topic-2") {
+				t.Errorf("MQTT5PubSub.Unsubscribe() error = %v, want reason code %#x", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNewMQTT_MQTT5(t *testing.T) {
+	tests := []struct {
+		name     string
+		connack  []byte
+		wantCode byte
+		wantErr  bool
+	}{
+		{
+			name:    "connected",
+			connack: []byte{0x20, 0x03, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "not authorized",
+			connack:  []byte{0x20, 0x03, 0x00, 0x87, 0x00},
+			wantCode: 0x87,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			// the stand-in broker answers the first CONNECT it receives and then discards everything else
+			go func() {
+				c, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer c.Close()
+
+				if _, err := c.Read(make([]byte, 1024)); err != nil {
+					return
+				}
+				if _, err := c.Write(tt.connack); err != nil {
+					return
+				}
+				_, _ = io.Copy(io.Discard, c)
+			}()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m, err := NewMQTT(ctx, Config{
+				Scheme:   "tcp",
+				Host:     "127.0.0.1",
+				Port:     l.Addr().(*net.TCPAddr).Port,
+				Username: "test-username",
+				Password: "test-password",
+				Protocol: 5,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMQTT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var rce *ReasonCodeError
+			if tt.wantCode != 0 && (!errors.As(err, &rce) || rce.Code != tt.wantCode) {
+				t.Errorf("NewMQTT() error = %v, want reason code %#x", err, tt.wantCode)
+			}
+
+			if !tt.wantErr {
+				r, ok := m.Client.(*ResubscribingPubSub)
+				if !ok {
+					t.Fatalf("NewMQTT() client = %T, want *ResubscribingPubSub", m.Client)
+				}
+				if _, ok := r.Client.(*MQTT5PubSub); !ok {
+					t.Errorf("NewMQTT() client = %T, want *MQTT5PubSub", r.Client)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{filter: "a/b/c", topic: "a/b/c", want: true},
+		{filter: "a/b/c", topic: "a/b/d"},
+		{filter: "a/+/c", topic: "a/b/c", want: true},
+		{filter: "a/+", topic: "a/b/c"},
+		{filter: "a/#", topic: "a/b/c", want: true},
+		{filter: "a/#", topic: "a", want: true},
+		{filter: "a/b/c/d", topic: "a/b/c"},
+		{filter: "#", topic: "$SYS/uptime"},
+		{filter: "+/uptime", topic: "$SYS/uptime"},
+		{filter: "$SYS/#", topic: "$SYS/uptime", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.filter, tt.topic), func(t *testing.T) {
+			if got := MatchTopic(tt.filter, tt.topic); got != tt.want {
+				t.Errorf("MatchTopic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUserProperties(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties []string
+		want       paho5.UserProperties
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			properties: []string{"publisher=teslamate-discovery", "empty=", "equals=a=b"},
+			want: paho5.UserProperties{
+				{Key: "publisher", Value: "teslamate-discovery"},
+				{Key: "empty", Value: ""},
+				{Key: "equals", Value: "a=b"},
+			},
+		},
+		{
+			name: "none",
+		},
+		{
+			name:       "no separator",
+			properties: []string{"publisher"},
+			wantErr:    true,
+		},
+		{
+			name:       "no name",
+			properties: []string{"=teslamate-discovery"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewUserProperties(tt.properties)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewUserProperties() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewUserProperties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReasonCodeError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ReasonCodeError
+		want string
+	}{
+		{
+			name: "known",
+			err:  ReasonCodeError{Operation: "subscribe to test-topic", Code: 0x87, Reason: "test-reason"},
+			want: "subscribe to test-topic failed with reason code 0x87 (not authorized): test-reason",
+		},
+		{
+			name: "unknown",
+			err:  ReasonCodeError{Operation: "connect", Code: 0xfe},
+			want: "connect failed with reason code 0xfe",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("ReasonCodeError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionExpiryInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   uint32
+	}{
+		{name: "clean session", config: Config{CleanSession: true, SessionExpiry: time.Hour}, want: 0},
+		{name: "persistent session", config: Config{SessionExpiry: time.Hour}, want: 3600},
+		{name: "limited", config: Config{SessionExpiry: 200 * 365 * 24 * time.Hour}, want: math.MaxUint32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionExpiryInterval(tt.config); got != tt.want {
+				t.Errorf("SessionExpiryInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMQTT5PubSub_Receive_Queued(t *testing.T) {
+	ch := make(chan paho.Message, 1)
+	p := &MQTT5PubSub{Queued: func(_ paho.Client, m paho.Message) { ch <- m }}
+
+	handled, _ := p.Receive(paho5.PublishReceived{
+		Packet: &paho5.Publish{Topic: "test-topic", Payload: []byte("test-payload")},
+	})
+	if !handled {
+		t.Errorf("MQTT5PubSub.Receive() = %t, want true", handled)
+	}
+
+	if m := <-ch; m.Topic() != "test-topic" {
+		t.Errorf("MQTT5PubSub.Receive() queued = %v, want %v", m.Topic(), "test-topic")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
@@ -119,20 +120,88 @@ func TestMQTT_Publish(t *testing.T) {
 	}
 }
 
-func TestMQTT_Subscribe(t *testing.T) {
+func TestMQTT_Clear(t *testing.T) {
 	type fields struct {
 		Client stubPubSub
 	}
 	type args struct {
-		ctx   context.Context
-		topic string
+		ctx    context.Context
+		topics []string
 	}
 	tests := []struct {
 		name    string
 		fields  fields
 		args    args
-		want    paho.Message
+		want    []publishArgs
 		wantErr bool
+	}{
+		{
+			name: "default",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx:    context.Background(),
+				topics: []string{"test-topic-1", "test-topic-2"},
+			},
+			want: []publishArgs{
+				{topic: "test-topic-1", retained: true, payload: []byte{}},
+				{topic: "test-topic-2", retained: true, payload: []byte{}},
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("publish error")},
+					},
+				},
+			},
+			args: args{
+				ctx:    context.Background(),
+				topics: []string{"test-topic-1"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+			if err := m.Clear(tt.args.ctx, tt.args.topics...); (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.Clear() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(tt.fields.Client.publishArgs, tt.want) {
+				t.Errorf("MQTT.Clear() = %v, want %v", tt.fields.Client.publishArgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestMQTT_Subscribe(t *testing.T) {
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		ctx   context.Context
+		topic string
+		qos   byte
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		want         paho.Message
+		wantAttempts int
+		wantErr      bool
 	}{
 		{
 			name: "default",
@@ -147,6 +216,7 @@ func TestMQTT_Subscribe(t *testing.T) {
 			args: args{
 				ctx:   context.Background(),
 				topic: "test-topic",
+				qos:   1,
 			},
 			want: &stubMessage{messageId: 1},
 		},
@@ -165,21 +235,61 @@ func TestMQTT_Subscribe(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "packet identifier in use",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{
+						&stubToken{err: &ReasonCodeError{Operation: "subscribe to test-topic", Code: 0x91}},
+						&stubToken{},
+					},
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				topic: "test-topic",
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "packet identifier always in use",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{
+						&stubToken{err: &ReasonCodeError{Operation: "subscribe to test-topic", Code: 0x91}},
+					},
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				topic: "test-topic",
+			},
+			wantAttempts: SubscribeAttempts,
+			wantErr:      true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := &MQTT{
 				Client: &tt.fields.Client,
 			}
-			ch, err := m.Subscribe(tt.args.ctx, tt.args.topic)
+			ch, err := m.Subscribe(tt.args.ctx, tt.args.topic, tt.args.qos)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MQTT.Subscribe() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if tt.wantErr {
+
+			if tt.wantAttempts != 0 && len(tt.fields.Client.subscribeArgs) != tt.wantAttempts {
+				t.Errorf("MQTT.Subscribe() attempts = %d, want %d", len(tt.fields.Client.subscribeArgs), tt.wantAttempts)
+			}
+			if tt.wantErr || tt.want == nil {
 				return
 			}
 
+			if got := tt.fields.Client.subscribeArgs[0].qos; got != tt.args.qos {
+				t.Errorf("MQTT.Subscribe() qos = %d, want %d", got, tt.args.qos)
+			}
+
 			got := <-ch
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("MQTT.Subscribe() = %v, want %v", got, tt.want)
@@ -273,6 +383,42 @@ func TestBrokerURI(t *testing.T) {
 			},
 			want: "test-scheme://test-host:4242",
 		},
+		{
+			name: "websocket path",
+			args: args{
+				config: Config{
+					Scheme:    "wss",
+					Host:      "test-host",
+					Port:      4242,
+					WebSocket: WebSocketConfig{Path: "/test-path"},
+				},
+			},
+			want: "wss://test-host:4242/test-path",
+		},
+		{
+			name: "websocket relative path",
+			args: args{
+				config: Config{
+					Scheme:    "ws",
+					Host:      "test-host",
+					Port:      4242,
+					WebSocket: WebSocketConfig{Path: "test-path"},
+				},
+			},
+			want: "ws://test-host:4242/test-path",
+		},
+		{
+			name: "non-websocket path",
+			args: args{
+				config: Config{
+					Scheme:    "ssl",
+					Host:      "test-host",
+					Port:      4242,
+					WebSocket: WebSocketConfig{Path: "/test-path"},
+				},
+			},
+			want: "ssl://test-host:4242",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -282,3 +428,45 @@ func TestBrokerURI(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClientID(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		wantPrefix string
+		wantLen    int
+	}{
+		{
+			name:       "default",
+			config:     DefaultConfig,
+			wantPrefix: "teslamate-discovery-",
+			wantLen:    len("teslamate-discovery-") + 12,
+		},
+		{
+			name:       "custom",
+			config:     Config{ClientID: "test-client-id"},
+			wantPrefix: "test-client-id",
+			wantLen:    len("test-client-id"),
+		},
+		{
+			name:       "custom with suffix",
+			config:     Config{ClientID: "test-client-id", ClientIDSuffix: true},
+			wantPrefix: "test-client-id-",
+			wantLen:    len("test-client-id-") + 12,
+		},
+		{
+			name:       "empty",
+			config:     Config{},
+			wantPrefix: "teslamate-discovery",
+			wantLen:    len("teslamate-discovery"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewClientID(tt.config)
+			if !strings.HasPrefix(got, tt.wantPrefix) || len(got) != tt.wantLen {
+				t.Errorf("NewClientID() = %v, want %v with length %d", got, tt.wantPrefix, tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+const (
+	OriginName       = "teslamate-discovery"
+	OriginSupportURL = "https://github.com/nebhale/teslamate-discovery"
+)
+
+// Origin identifies the application that published the configuration, defaulting everything but the version, which
+// only the build knows.
+func Origin(haCfg ha.Config) ha.Origin {
+	o := haCfg.Origin
+
+	if o.Name == "" {
+		o.Name = OriginName
+	}
+	if o.SupportURL == "" {
+		o.SupportURL = OriginSupportURL
+	}
+
+	return o
+}
+
+func WithOrigin(entity interface{}, origin ha.Origin) interface{} {
+	e, ok := entity.(ha.Entity)
+	if !ok {
+		return entity
+	}
+
+	c := e.Common()
+	c.Origin = origin
+
+	return e.WithCommon(c)
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func (m *MQTT) Prune(ctx context.Context, retained []RetainedConfig, vehicles map[string]ha.Device, haCfg ha.Config,
+	unitsCfg units.Config) error {
+
+	current := make(map[string]bool)
+	for _, dev := range vehicles {
+		messages, err := DiscoveryMessages(dev, haCfg, unitsCfg)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			current[msg.Topic] = true
+		}
+	}
+
+	// only vehicles that were just published are pruned, anything else is left to purge
+	var stale []string
+	for _, r := range retained {
+		if _, ok := vehicles[r.VehicleId]; ok && !current[r.Topic] {
+			stale = append(stale, r.Topic)
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	fmt.Println("Pruning Stale Configurations")
+	return m.Clear(ctx, stale...)
+}
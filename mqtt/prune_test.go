@@ -0,0 +1,140 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/units"
+)
+
+func TestMQTT_Prune(t *testing.T) {
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		ctx      context.Context
+		retained []RetainedConfig
+		vehicles map[string]ha.Device
+		haCfg    ha.Config
+		unitsCfg units.Config
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "stale",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				retained: []RetainedConfig{
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/range/config", VehicleId: "1"},
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/stale/config", VehicleId: "1"},
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_2/stale/config", VehicleId: "2"},
+				},
+				vehicles: map[string]ha.Device{
+					"1": {Identifiers: []string{"test-prefix/cars/1"}},
+				},
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+			},
+			want: []string{
+				"test-discovery-prefix/sensor/test-prefix_cars_1/stale/config",
+			},
+		},
+		{
+			name: "current",
+			args: args{
+				ctx: context.Background(),
+				retained: []RetainedConfig{
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/range/config", VehicleId: "1"},
+				},
+				vehicles: map[string]ha.Device{
+					"1": {Identifiers: []string{"test-prefix/cars/1"}},
+				},
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+			},
+		},
+		{
+			name: "device mode",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				retained: []RetainedConfig{
+					{Topic: "test-discovery-prefix/device/test-prefix_cars_1/config", VehicleId: "1"},
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/range/config", VehicleId: "1"},
+				},
+				vehicles: map[string]ha.Device{
+					"1": {Identifiers: []string{"test-prefix/cars/1"}},
+				},
+				haCfg: ha.Config{DiscoveryMode: ha.DeviceDiscovery, DiscoveryPrefix: "test-discovery-prefix"},
+			},
+			want: []string{
+				"test-discovery-prefix/sensor/test-prefix_cars_1/range/config",
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("publish error")},
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				retained: []RetainedConfig{
+					{Topic: "test-discovery-prefix/sensor/test-prefix_cars_1/stale/config", VehicleId: "1"},
+				},
+				vehicles: map[string]ha.Device{
+					"1": {Identifiers: []string{"test-prefix/cars/1"}},
+				},
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+			if err := m.Prune(tt.args.ctx, tt.args.retained, tt.args.vehicles, tt.args.haCfg, tt.args.unitsCfg); (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.Prune() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var topics []string
+			for _, v := range tt.fields.Client.publishArgs {
+				topics = append(topics, v.topic)
+			}
+
+			if !reflect.DeepEqual(topics, tt.want) {
+				t.Errorf("MQTT.Prune() topics = %v, want %v", topics, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+type PublishSummary struct {
+	New       int
+	Updated   int
+	Unchanged int
+}
+
+func (p PublishSummary) Add(o PublishSummary) PublishSummary {
+	return PublishSummary{
+		New:       p.New + o.New,
+		Updated:   p.Updated + o.Updated,
+		Unchanged: p.Unchanged + o.Unchanged,
+	}
+}
+
+func (m *MQTT) PublishChanged(ctx context.Context, retained []RetainedConfig, messages ...Message) (PublishSummary, error) {
+	existing := make(map[string][]byte, len(retained))
+	for _, r := range retained {
+		existing[r.Topic] = r.Payload
+	}
+
+	var s PublishSummary
+	var changed []Message
+
+	for _, msg := range messages {
+		old, ok := existing[msg.Topic]
+
+		switch {
+		case !ok:
+			s.New++
+		case EqualJSON(old, msg.Payload):
+			s.Unchanged++
+			continue
+		default:
+			s.Updated++
+		}
+
+		changed = append(changed, msg)
+	}
+
+	return s, m.PublishMessages(ctx, changed...)
+}
+
+func CanonicalJSON(b []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	// maps are marshaled with sorted keys, so semantically equal documents produce identical bytes
+	return json.Marshal(v)
+}
+
+func EqualJSON(a []byte, b []byte) bool {
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		return false
+	}
+
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(ca, cb)
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestMQTT_PublishChanged(t *testing.T) {
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		ctx      context.Context
+		retained []RetainedConfig
+		messages []Message
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		want       PublishSummary
+		wantTopics []string
+		wantErr    bool
+	}{
+		{
+			name: "default",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				retained: []RetainedConfig{
+					{Topic: "test-topic-unchanged", Payload: []byte(`{"a":"1","b":"2"}`)},
+					{Topic: "test-topic-updated", Payload: []byte(`{"a":"1"}`)},
+					{Topic: "test-topic-invalid", Payload: []byte(`{`)},
+				},
+				messages: []Message{
+					{Topic: "test-topic-unchanged", Payload: []byte(`{"b":"2","a":"1"}`)},
+					{Topic: "test-topic-updated", Payload: []byte(`{"a":"2"}`)},
+					{Topic: "test-topic-invalid", Payload: []byte(`{"a":"1"}`)},
+					{Topic: "test-topic-new", Payload: []byte(`{"a":"1"}`)},
+				},
+			},
+			want: PublishSummary{New: 1, Updated: 2, Unchanged: 1},
+			wantTopics: []string{
+				"test-topic-updated",
+				"test-topic-invalid",
+				"test-topic-new",
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					publishTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("publish error")},
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				messages: []Message{
+					{Topic: "test-topic-new", Payload: []byte(`{"a":"1"}`)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+			got, err := m.PublishChanged(tt.args.ctx, tt.args.retained, tt.args.messages...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.PublishChanged() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("MQTT.PublishChanged() = %v, want %v", got, tt.want)
+			}
+
+			var topics []string
+			for _, v := range tt.fields.Client.publishArgs {
+				topics = append(topics, v.topic)
+			}
+
+			if !reflect.DeepEqual(topics, tt.wantTopics) {
+				t.Errorf("MQTT.PublishChanged() topics = %v, want %v", topics, tt.wantTopics)
+			}
+		})
+	}
+}
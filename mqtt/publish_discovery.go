@@ -6,7 +6,6 @@ package mqtt
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/iancoleman/strcase"
 
@@ -14,12 +13,39 @@ import (
 	"github.com/nebhale/teslamate-discovery/units"
 )
 
+// ReleaseNotesURL is the address of the release notes for each software version, which follows it in the path.
+const ReleaseNotesURL = "https://www.notateslaapp.com/software-updates/version/"
+
 func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device, haCfg ha.Config,
 	unitsCfg units.Config) error {
 
 	fmt.Printf("Configuring %s\n", device.Name)
 
-	v := []interface{}{
+	messages, err := DiscoveryMessages(device, haCfg, unitsCfg)
+	if err != nil {
+		return err
+	}
+
+	return m.PublishMessages(ctx, messages...)
+}
+
+func (m *MQTT) PublishDiscoveryChanged(ctx context.Context, id string, device ha.Device, retained []RetainedConfig,
+	haCfg ha.Config, unitsCfg units.Config) (PublishSummary, error) {
+
+	fmt.Printf("Configuring %s\n", device.Name)
+
+	messages, err := DiscoveryMessages(device, haCfg, unitsCfg)
+	if err != nil {
+		return PublishSummary{}, err
+	}
+
+	return m.PublishChanged(ctx, retained, messages...)
+}
+
+func Entities(device ha.Device, haCfg ha.Config, unitsCfg units.Config) []interface{} {
+	// entities that describe the vehicle rather than what it is doing are diagnostic, and those that are noisy or
+	// rarely useful start disabled until enabled in Home Assistant
+	entities := []interface{}{
 
 		// Charge
 		ha.Sensor{
@@ -33,6 +59,8 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 		ha.Sensor{
 			Device:            device,
 			DeviceClass:       ha.Current,
+			EnabledByDefault:  new(false),
+			EntityCategory:    ha.Diagnostic,
 			Name:              "Charge Current Request (Max)",
 			StateTopic:        StateTopic(device, "/charge_current_request_max"),
 			UniqueId:          UniqueId(device, "/charge_current_request_max"),
@@ -82,11 +110,13 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			UniqueId:    UniqueId(device, "/plug"),
 		},
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:sine-wave",
-			Name:       "Charger Phases",
-			StateTopic: StateTopic(device, "/charger_phases"),
-			UniqueId:   UniqueId(device, "/charger_phases"),
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:sine-wave",
+			Name:             "Charger Phases",
+			StateTopic:       StateTopic(device, "/charger_phases"),
+			UniqueId:         UniqueId(device, "/charger_phases"),
 		},
 		ha.Sensor{
 			Device:            device,
@@ -166,6 +196,8 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 		// Location
 		ha.Sensor{
 			Device:            device,
+			EnabledByDefault:  new(false),
+			EntityCategory:    ha.Diagnostic,
 			Icon:              "mdi:image-filter-hdr",
 			Name:              "Elevation",
 			StateTopic:        StateTopic(device, "/elevation"),
@@ -182,6 +214,8 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 		},
 		ha.Sensor{
 			Device:            device,
+			EnabledByDefault:  new(false),
+			EntityCategory:    ha.Diagnostic,
 			Icon:              "mdi:compass",
 			Name:              "Heading",
 			StateTopic:        StateTopic(device, "/heading"),
@@ -218,25 +252,31 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 
 		// State
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:format-color-fill",
-			Name:       "Exterior Color",
-			StateTopic: StateTopic(device, "/exterior_color"),
-			UniqueId:   UniqueId(device, "/exterior_color"),
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:format-color-fill",
+			Name:             "Exterior Color",
+			StateTopic:       StateTopic(device, "/exterior_color"),
+			UniqueId:         UniqueId(device, "/exterior_color"),
 		},
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:weather-windy",
-			Name:       "Spoiler Type",
-			StateTopic: StateTopic(device, "/spoiler_type"),
-			UniqueId:   UniqueId(device, "/spoiler_type"),
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:weather-windy",
+			Name:             "Spoiler Type",
+			StateTopic:       StateTopic(device, "/spoiler_type"),
+			UniqueId:         UniqueId(device, "/spoiler_type"),
 		},
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:form-textbox",
-			Name:       "Display Name",
-			StateTopic: StateTopic(device, "/display_name"),
-			UniqueId:   UniqueId(device, "/display_name"),
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:form-textbox",
+			Name:             "Display Name",
+			StateTopic:       StateTopic(device, "/display_name"),
+			UniqueId:         UniqueId(device, "/display_name"),
 		},
 		ha.Sensor{
 			Device:            device,
@@ -250,6 +290,7 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 		ha.Sensor{
 			Device:            device,
 			DeviceClass:       ha.BatteryCharge,
+			EntityCategory:    ha.Diagnostic,
 			Name:              "Usable Battery",
 			StateClass:        ha.Measurement,
 			StateTopic:        StateTopic(device, "/usable_battery_level"),
@@ -257,12 +298,14 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			UnitOfMeasurement: "%",
 		},
 		ha.Sensor{
-			Device:        device,
-			Icon:          "mdi:television",
-			Name:          "Center Display",
-			StateTopic:    StateTopic(device, "/center_display_state"),
-			UniqueId:      UniqueId(device, "/center_display"),
-			ValueTemplate: `{% if value == "0" %}Off{% elif value == "2" %}Standby{% elif value == "3" %}Charging{% elif value == "4" %}On{% elif value == "5" %}Big Charging{% elif value == "6" %}Ready to Unlock{% elif value == "7" %}Sentry Mode{% elif value == "8" %}Dog Mode{% elif value == "9" %}Media{% else %}Unknown{% endif %}`,
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:television",
+			Name:             "Center Display",
+			StateTopic:       StateTopic(device, "/center_display_state"),
+			UniqueId:         UniqueId(device, "/center_display"),
+			ValueTemplate:    `{% if value == "0" %}Off{% elif value == "2" %}Standby{% elif value == "3" %}Charging{% elif value == "4" %}On{% elif value == "5" %}Big Charging{% elif value == "6" %}Ready to Unlock{% elif value == "7" %}Sentry Mode{% elif value == "8" %}Dog Mode{% elif value == "9" %}Media{% else %}Unknown{% endif %}`,
 		},
 		ha.BinarySensor{
 			Device:      device,
@@ -315,14 +358,15 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			UniqueId:    UniqueId(device, "/frunk"),
 		},
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Problem,
-			Icon:        "mdi:heart-pulse",
-			Name:        "Health",
-			PayloadOff:  "true",
-			PayloadOn:   "false",
-			StateTopic:  StateTopic(device, "/healthy"),
-			UniqueId:    UniqueId(device, "/health"),
+			Device:         device,
+			DeviceClass:    ha.Problem,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:heart-pulse",
+			Name:           "Health",
+			PayloadOff:     "true",
+			PayloadOn:      "false",
+			StateTopic:     StateTopic(device, "/healthy"),
+			UniqueId:       UniqueId(device, "/health"),
 		},
 		ha.BinarySensor{
 			Device:      device,
@@ -407,11 +451,12 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			UniqueId:   UniqueId(device, "/state"),
 		},
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:timer-sand",
-			Name:       "Last Seen",
-			StateTopic: StateTopic(device, "/since"),
-			UniqueId:   UniqueId(device, "/since"),
+			Device:         device,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:timer-sand",
+			Name:           "Last Seen",
+			StateTopic:     StateTopic(device, "/since"),
+			UniqueId:       UniqueId(device, "/since"),
 		},
 		ha.Sensor{
 			Device:            device,
@@ -458,44 +503,48 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			ValueTemplate:     unitsCfg.Pressure.PressureValueTemplate(),
 		},
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Problem,
-			Icon:        "mdi:car-tire-alert",
-			Name:        "Tire Soft (Front Left)",
-			PayloadOff:  "false",
-			PayloadOn:   "true",
-			StateTopic:  StateTopic(device, "/tpms_soft_warning_fl"),
-			UniqueId:    UniqueId(device, "/tire_soft_front_left"),
+			Device:         device,
+			DeviceClass:    ha.Problem,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:car-tire-alert",
+			Name:           "Tire Soft (Front Left)",
+			PayloadOff:     "false",
+			PayloadOn:      "true",
+			StateTopic:     StateTopic(device, "/tpms_soft_warning_fl"),
+			UniqueId:       UniqueId(device, "/tire_soft_front_left"),
 		},
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Problem,
-			Icon:        "mdi:car-tire-alert",
-			Name:        "Tire Soft (Front Right)",
-			PayloadOff:  "false",
-			PayloadOn:   "true",
-			StateTopic:  StateTopic(device, "/tpms_soft_warning_fr"),
-			UniqueId:    UniqueId(device, "/tire_soft_front_right"),
+			Device:         device,
+			DeviceClass:    ha.Problem,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:car-tire-alert",
+			Name:           "Tire Soft (Front Right)",
+			PayloadOff:     "false",
+			PayloadOn:      "true",
+			StateTopic:     StateTopic(device, "/tpms_soft_warning_fr"),
+			UniqueId:       UniqueId(device, "/tire_soft_front_right"),
 		},
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Problem,
-			Icon:        "mdi:car-tire-alert",
-			Name:        "Tire Soft (Rear Left)",
-			PayloadOff:  "false",
-			PayloadOn:   "true",
-			StateTopic:  StateTopic(device, "/tpms_soft_warning_rl"),
-			UniqueId:    UniqueId(device, "/tire_soft_rear_left"),
+			Device:         device,
+			DeviceClass:    ha.Problem,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:car-tire-alert",
+			Name:           "Tire Soft (Rear Left)",
+			PayloadOff:     "false",
+			PayloadOn:      "true",
+			StateTopic:     StateTopic(device, "/tpms_soft_warning_rl"),
+			UniqueId:       UniqueId(device, "/tire_soft_rear_left"),
 		},
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Problem,
-			Icon:        "mdi:car-tire-alert",
-			Name:        "Tire Soft (Rear Right)",
-			PayloadOff:  "false",
-			PayloadOn:   "true",
-			StateTopic:  StateTopic(device, "/tpms_soft_warning_rr"),
-			UniqueId:    UniqueId(device, "/tire_soft_rear_right"),
+			Device:         device,
+			DeviceClass:    ha.Problem,
+			EntityCategory: ha.Diagnostic,
+			Icon:           "mdi:car-tire-alert",
+			Name:           "Tire Soft (Rear Right)",
+			PayloadOff:     "false",
+			PayloadOn:      "true",
+			StateTopic:     StateTopic(device, "/tpms_soft_warning_rr"),
+			UniqueId:       UniqueId(device, "/tire_soft_rear_right"),
 		},
 		ha.BinarySensor{
 			Device:      device,
@@ -507,14 +556,18 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			StateTopic:  StateTopic(device, "/trunk_open"),
 			UniqueId:    UniqueId(device, "/trunk"),
 		},
+		// the software update entity replaces the update binary sensor and version sensor, which are kept disabled
+		// for the automations that still use them
 		ha.BinarySensor{
-			Device:      device,
-			DeviceClass: ha.Update,
-			Name:        "Update",
-			PayloadOff:  "false",
-			PayloadOn:   "true",
-			StateTopic:  StateTopic(device, "/update_available"),
-			UniqueId:    UniqueId(device, "/update"),
+			Device:           device,
+			DeviceClass:      ha.Update,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Name:             "Update",
+			PayloadOff:       "false",
+			PayloadOn:        "true",
+			StateTopic:       StateTopic(device, "/update_available"),
+			UniqueId:         UniqueId(device, "/update"),
 		},
 		ha.BinarySensor{
 			Device:      device,
@@ -527,15 +580,34 @@ func (m *MQTT) PublishDiscovery(ctx context.Context, id string, device ha.Device
 			UniqueId:    UniqueId(device, "/windows"),
 		},
 		ha.Sensor{
-			Device:     device,
-			Icon:       "mdi:numeric",
-			Name:       "Version",
-			StateTopic: StateTopic(device, "/version"),
-			UniqueId:   UniqueId(device, "/version"),
+			Device:           device,
+			EnabledByDefault: new(false),
+			EntityCategory:   ha.Diagnostic,
+			Icon:             "mdi:numeric",
+			Name:             "Version",
+			StateTopic:       StateTopic(device, "/version"),
+			UniqueId:         UniqueId(device, "/version"),
+		},
+		// the installed version is rendered as json so that the release notes can follow it, and with no update
+		// pending the latest version is the installed one
+		ha.UpdateEntity{
+			Device:                device,
+			DeviceClass:           ha.Firmware,
+			LatestVersionTemplate: `{{ value.split(" ")[0] if value | trim else this.attributes.installed_version }}`,
+			LatestVersionTopic:    StateTopic(device, "/update_version"),
+			Name:                  "Software",
+			StateTopic:            StateTopic(device, "/version"),
+			Title:                 "Tesla Software",
+			UniqueId:              UniqueId(device, "/software_update"),
+			ValueTemplate:         `{% set v = value.split(" ")[0] %}{{ {"installed_version": v, "release_url": "` + ReleaseNotesURL + `" ~ v ~ "/release-notes"} | to_json }}`,
 		},
 	}
 
-	return m.Publish(ctx, haCfg.DiscoveryPrefix, v...)
+	for i, e := range entities {
+		entities[i] = WithAvailability(e, Availability(device, haCfg, e))
+	}
+
+	return entities
 }
 
 func StateTopic(device ha.Device, suffix string) string {
@@ -543,5 +615,5 @@ func StateTopic(device ha.Device, suffix string) string {
 }
 
 func UniqueId(device ha.Device, suffix string) string {
-	return fmt.Sprintf("%s%s", strings.ReplaceAll(device.Identifiers[0], "/", "_"), suffix)
+	return fmt.Sprintf("%s%s", NodeId(device), suffix)
 }
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"testing"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
@@ -109,6 +110,7 @@ func TestMQTT_PublishDiscovery(t *testing.T) {
 				"test-discovery-prefix/binary_sensor/test-id/update/config",
 				"test-discovery-prefix/binary_sensor/test-id/windows/config",
 				"test-discovery-prefix/sensor/test-id/version/config",
+				"test-discovery-prefix/update/test-id/software_update/config",
 			},
 		},
 		{
@@ -219,3 +221,72 @@ func TestUniqueId(t *testing.T) {
 		})
 	}
 }
+
+func TestEntities_Category(t *testing.T) {
+	d := ha.Device{Identifiers: []string{"test-id"}}
+
+	got := make(map[string]ha.Sensor)
+	binary := make(map[string]ha.BinarySensor)
+	for _, e := range Entities(d, ha.Config{}, units.Config{}) {
+		switch e := e.(type) {
+		case ha.Sensor:
+			// home assistant rejects sensors in the config category, which is only for entities that change settings
+			if e.EntityCategory == ha.Configuration {
+				t.Errorf("Entities() %s category = %s, want not %s", e.Name, e.EntityCategory, ha.Configuration)
+			}
+			got[e.UniqueId] = e
+		case ha.BinarySensor:
+			if e.EntityCategory == ha.Configuration {
+				t.Errorf("Entities() %s category = %s, want not %s", e.Name, e.EntityCategory, ha.Configuration)
+			}
+			binary[e.UniqueId] = e
+		}
+	}
+
+	if e := got["test-id/battery"]; e.EntityCategory != "" || e.EnabledByDefault != nil {
+		t.Errorf("Entities() battery = %s, %v, want primary and enabled", e.EntityCategory, e.EnabledByDefault)
+	}
+	if e := got["test-id/version"]; e.EntityCategory != ha.Diagnostic || e.EnabledByDefault == nil || *e.EnabledByDefault {
+		t.Errorf("Entities() version = %s, %v, want diagnostic and disabled", e.EntityCategory, e.EnabledByDefault)
+	}
+	if e := binary["test-id/update"]; e.EntityCategory != ha.Diagnostic || e.EnabledByDefault == nil || *e.EnabledByDefault {
+		t.Errorf("Entities() update = %s, %v, want diagnostic and disabled", e.EntityCategory, e.EnabledByDefault)
+	}
+	if e := got["test-id/charger_phases"]; e.EntityCategory != ha.Diagnostic || e.EnabledByDefault == nil || *e.EnabledByDefault {
+		t.Errorf("Entities() charger phases = %s, %v, want diagnostic and disabled", e.EntityCategory, e.EnabledByDefault)
+	}
+}
+
+func TestEntities_Update(t *testing.T) {
+	d := ha.Device{Identifiers: []string{"test-id"}}
+
+	var got []ha.UpdateEntity
+	for _, e := range Entities(d, ha.Config{}, units.Config{}) {
+		if e, ok := e.(ha.UpdateEntity); ok {
+			got = append(got, e)
+		}
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("Entities() updates = %d, want 1", len(got))
+	}
+	if got[0].DeviceClass != ha.Firmware {
+		t.Errorf("Entities() update device class = %s, want %s", got[0].DeviceClass, ha.Firmware)
+	}
+	if want := "test-id/version"; got[0].StateTopic != want {
+		t.Errorf("Entities() update state topic = %s, want %s", got[0].StateTopic, want)
+	}
+	if want := "test-id/update_version"; got[0].LatestVersionTopic != want {
+		t.Errorf("Entities() update latest version topic = %s, want %s", got[0].LatestVersionTopic, want)
+	}
+
+	if topic, err := DiscoveryTopic("test-discovery-prefix", got[0]); err != nil || topic != "test-discovery-prefix/update/test-id/software_update/config" {
+		t.Errorf("DiscoveryTopic() = %s, %v, want %s", topic, err, "test-discovery-prefix/update/test-id/software_update/config")
+	}
+	if id, c, err := Component(d, got[0]); err != nil || id != "software_update" || c.(ha.UpdateEntity).Platform != "update" {
+		t.Errorf("Component() = %s, %v, %v, want %s on %s", id, c, err, "software_update", "update")
+	}
+	if topics := EntityTopics(got[0]); !slices.Contains(topics, "test-id/update_version") {
+		t.Errorf("EntityTopics() = %v, want %s", topics, "test-id/update_version")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+type Unacknowledged struct {
+	Topic string
+	Err   error
+}
+
+type UnacknowledgedError struct {
+	Messages []Unacknowledged
+}
+
+func (e *UnacknowledgedError) Error() string {
+	if len(e.Messages) == 1 {
+		return fmt.Sprintf("message to %s was not acknowledged: %s", e.Messages[0].Topic, e.Messages[0].Err)
+	}
+
+	return fmt.Sprintf("%d messages were not acknowledged", len(e.Messages))
+}
+
+// Collect absorbs the messages of an UnacknowledgedError so that publishing can continue, returning false for any
+// other error.
+func (e *UnacknowledgedError) Collect(err error) bool {
+	var u *UnacknowledgedError
+	if errors.As(err, &u) {
+		e.Messages = append(e.Messages, u.Messages...)
+		return true
+	}
+
+	return err == nil
+}
+
+func (e *UnacknowledgedError) Err() error {
+	if len(e.Messages) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+type inFlight struct {
+	topic    string
+	token    paho.Token
+	deadline <-chan time.Time
+	stop     func() bool
+}
+
+func (m *MQTT) PublishWindow(ctx context.Context, retained bool, messages ...Message) error {
+	size := m.InFlight
+	if size < 1 {
+		size = 1
+	}
+
+	var window []inFlight
+	var unacked UnacknowledgedError
+
+	for _, msg := range messages {
+		if len(window) == size {
+			if !m.await(ctx, window[0], &unacked) {
+				return ctx.Err()
+			}
+			window = window[1:]
+		}
+
+		fmt.Printf("  %s\n", msg.Topic)
+
+		f := inFlight{
+			topic: msg.Topic,
+			token: m.Client.Publish(msg.Topic, m.QoS, retained, msg.Payload),
+			stop:  func() bool { return false },
+		}
+		if m.AckTimeout > 0 {
+			t := time.NewTimer(m.AckTimeout)
+			f.deadline, f.stop = t.C, t.Stop
+		}
+		window = append(window, f)
+	}
+
+	for _, f := range window {
+		if !m.await(ctx, f, &unacked) {
+			return ctx.Err()
+		}
+	}
+
+	return unacked.Err()
+}
+
+func (m *MQTT) await(ctx context.Context, f inFlight, unacked *UnacknowledgedError) bool {
+	defer f.stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-f.token.Done():
+	case <-f.deadline:
+		// an acknowledgement that arrived while earlier messages were being awaited still counts
+		select {
+		case <-f.token.Done():
+		default:
+			unacked.Messages = append(unacked.Messages, Unacknowledged{
+				Topic: f.topic,
+				Err:   fmt.Errorf("no acknowledgement within %s", m.AckTimeout),
+			})
+			return true
+		}
+	}
+
+	if err := f.token.Error(); err != nil {
+		unacked.Messages = append(unacked.Messages, Unacknowledged{Topic: f.topic, Err: err})
+	}
+
+	return true
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+// countingPubSub tracks how many published messages have not yet been acknowledged
+type countingPubSub struct {
+	stubPubSub
+	outstanding int
+	max         int
+}
+
+type countingToken struct {
+	stubToken
+	p *countingPubSub
+}
+
+func (c *countingToken) Done() <-chan struct{} {
+	c.p.outstanding--
+	return c.stubToken.Done()
+}
+
+func (c *countingPubSub) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	c.stubPubSub.Publish(topic, qos, retained, payload)
+
+	c.outstanding++
+	if c.outstanding > c.max {
+		c.max = c.outstanding
+	}
+
+	return &countingToken{p: c}
+}
+
+type pendingToken struct {
+	stubToken
+}
+
+func (p *pendingToken) Done() <-chan struct{} {
+	return make(chan struct{})
+}
+
+func TestMQTT_PublishWindow(t *testing.T) {
+	messages := []Message{
+		{Topic: "test-topic-1", Payload: []byte("test-payload-1")},
+		{Topic: "test-topic-2", Payload: []byte("test-payload-2")},
+		{Topic: "test-topic-3", Payload: []byte("test-payload-3")},
+	}
+
+	type fields struct {
+		QoS        byte
+		NoRetain   bool
+		AckTimeout time.Duration
+		Tokens     []paho.Token
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   []publishArgs
+		wantU  []string
+	}{
+		{
+			name:   "acknowledged",
+			fields: fields{QoS: 1, Tokens: []paho.Token{&stubToken{}}},
+			want: []publishArgs{
+				{topic: "test-topic-1", qos: 1, retained: true, payload: []byte("test-payload-1")},
+				{topic: "test-topic-2", qos: 1, retained: true, payload: []byte("test-payload-2")},
+				{topic: "test-topic-3", qos: 1, retained: true, payload: []byte("test-payload-3")},
+			},
+		},
+		{
+			name:   "not retained",
+			fields: fields{QoS: 2, NoRetain: true, Tokens: []paho.Token{&stubToken{}}},
+			want: []publishArgs{
+				{topic: "test-topic-1", qos: 2, payload: []byte("test-payload-1")},
+				{topic: "test-topic-2", qos: 2, payload: []byte("test-payload-2")},
+				{topic: "test-topic-3", qos: 2, payload: []byte("test-payload-3")},
+			},
+		},
+		{
+			name: "errors",
+			fields: fields{QoS: 1, Tokens: []paho.Token{
+				&stubToken{err: fmt.Errorf("publish error")},
+				&stubToken{},
+				&stubToken{err: fmt.Errorf("publish error")},
+			}},
+			want: []publishArgs{
+				{topic: "test-topic-1", qos: 1, retained: true, payload: []byte("test-payload-1")},
+				{topic: "test-topic-2", qos: 1, retained: true, payload: []byte("test-payload-2")},
+				{topic: "test-topic-3", qos: 1, retained: true, payload: []byte("test-payload-3")},
+			},
+			wantU: []string{"test-topic-1", "test-topic-3"},
+		},
+		{
+			name: "timeout",
+			fields: fields{QoS: 1, AckTimeout: 10 * time.Millisecond, Tokens: []paho.Token{
+				&stubToken{},
+				&pendingToken{},
+				&stubToken{},
+			}},
+			want: []publishArgs{
+				{topic: "test-topic-1", qos: 1, retained: true, payload: []byte("test-payload-1")},
+				{topic: "test-topic-2", qos: 1, retained: true, payload: []byte("test-payload-2")},
+				{topic: "test-topic-3", qos: 1, retained: true, payload: []byte("test-payload-3")},
+			},
+			wantU: []string{"test-topic-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &stubPubSub{publishTokens: tt.fields.Tokens}
+			m := &MQTT{
+				Client:     c,
+				QoS:        tt.fields.QoS,
+				NoRetain:   tt.fields.NoRetain,
+				InFlight:   2,
+				AckTimeout: tt.fields.AckTimeout,
+			}
+
+			err := m.PublishMessages(context.Background(), messages...)
+
+			var got []string
+			var u *UnacknowledgedError
+			if errors.As(err, &u) {
+				for _, m := range u.Messages {
+					got = append(got, m.Topic)
+				}
+			} else if err != nil {
+				t.Fatalf("MQTT.PublishMessages() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.wantU) {
+				t.Errorf("MQTT.PublishMessages() unacknowledged = %v, want %v", got, tt.wantU)
+			}
+
+			if !reflect.DeepEqual(c.publishArgs, tt.want) {
+				t.Errorf("MQTT.PublishMessages() published = %v, want %v", c.publishArgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestMQTT_PublishWindow_InFlight(t *testing.T) {
+	messages := make([]Message, 10)
+	for i := range messages {
+		messages[i] = Message{Topic: fmt.Sprintf("test-topic-%d", i)}
+	}
+
+	for _, size := range []int{0, 1, 3, 20} {
+		t.Run(fmt.Sprintf("in-flight %d", size), func(t *testing.T) {
+			c := &countingPubSub{}
+			m := &MQTT{Client: c, InFlight: size}
+
+			if err := m.PublishMessages(context.Background(), messages...); err != nil {
+				t.Fatalf("MQTT.PublishMessages() error = %v", err)
+			}
+
+			want := min(max(size, 1), len(messages))
+			if c.max != want {
+				t.Errorf("MQTT.PublishMessages() in-flight = %d, want %d", c.max, want)
+			}
+			if c.outstanding != 0 {
+				t.Errorf("MQTT.PublishMessages() outstanding = %d, want 0", c.outstanding)
+			}
+		})
+	}
+}
+
+func TestUnacknowledgedError_Collect(t *testing.T) {
+	var u UnacknowledgedError
+
+	if !u.Collect(nil) {
+		t.Errorf("UnacknowledgedError.Collect(nil) = false, want true")
+	}
+	if u.Err() != nil {
+		t.Errorf("UnacknowledgedError.Err() = %v, want nil", u.Err())
+	}
+
+	if !u.Collect(fmt.Errorf("wrapped: %w", &UnacknowledgedError{Messages: []Unacknowledged{{Topic: "test-topic"}}})) {
+		t.Errorf("UnacknowledgedError.Collect(unacknowledged) = false, want true")
+	}
+	if u.Collect(fmt.Errorf("other error")) {
+		t.Errorf("UnacknowledgedError.Collect(other) = true, want false")
+	}
+
+	if len(u.Messages) != 1 || u.Err() == nil {
+		t.Errorf("UnacknowledgedError.Messages = %v, want one message", u.Messages)
+	}
+}
@@ -4,34 +4,26 @@
 package mqtt
 
 import (
-	"math/rand"
-	"time"
-	"unsafe"
+	"crypto/rand"
 )
 
-const (
-	letterBytes   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	letterIdxBits = 6                    // 6 bits to represent a letter index
-	letterIdxMask = 1<<letterIdxBits - 1 // All 1-bits, as many as letterIdxBits
-	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
-)
-
-var src = rand.NewSource(time.Now().UnixNano())
+const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
 func RandomString(n int) string {
+	s := make([]byte, 0, n)
 	b := make([]byte, n)
-	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
-		}
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			b[i] = letterBytes[idx]
-			i--
+
+	for len(s) < n {
+		_, _ = rand.Read(b) // never returns an error
+
+		for _, c := range b {
+			// bytes beyond the largest multiple of the alphabet's length would favor its first letters
+			if int(c) >= len(letterBytes)*(256/len(letterBytes)) || len(s) == n {
+				continue
+			}
+			s = append(s, letterBytes[int(c)%len(letterBytes)])
 		}
-		cache >>= letterIdxBits
-		remain--
 	}
 
-	return *(*string)(unsafe.Pointer(&b))
+	return string(s)
 }
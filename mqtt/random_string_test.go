@@ -4,6 +4,7 @@
 package mqtt_test
 
 import (
+	"strings"
 	"testing"
 
 	. "github.com/nebhale/teslamate-discovery/mqtt"
@@ -31,9 +32,16 @@ func TestRandomString(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := RandomString(tt.args.n); len(got) != tt.want {
+			got := RandomString(tt.args.n)
+			if len(got) != tt.want {
 				t.Errorf("RandomString() = %v, want %v", got, tt.want)
 			}
+			if strings.Trim(got, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+				t.Errorf("RandomString() = %v, want only letters", got)
+			}
+			if again := RandomString(tt.args.n); again == got {
+				t.Errorf("RandomString() = %v twice", got)
+			}
 		})
 	}
 }
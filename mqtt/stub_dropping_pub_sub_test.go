@@ -0,0 +1,66 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"sync"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// stubDroppingPubSub delivers messages to its current subscriptions and loses them all when the connection drops, as a
+// broker does for a clean session.
+type stubDroppingPubSub struct {
+	mu            sync.Mutex
+	subscriptions map[string]paho.MessageHandler
+	subscribed    chan string
+}
+
+func newStubDroppingPubSub() *stubDroppingPubSub {
+	return &stubDroppingPubSub{
+		subscriptions: make(map[string]paho.MessageHandler),
+		subscribed:    make(chan string, 10),
+	}
+}
+
+func (s *stubDroppingPubSub) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	return &stubToken{}
+}
+
+func (s *stubDroppingPubSub) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	s.mu.Lock()
+	s.subscriptions[topic] = callback
+	s.mu.Unlock()
+
+	s.subscribed <- topic
+	return &stubToken{}
+}
+
+func (s *stubDroppingPubSub) Unsubscribe(topics ...string) paho.Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range topics {
+		delete(s.subscriptions, t)
+	}
+	return &stubToken{}
+}
+
+func (s *stubDroppingPubSub) Drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions = make(map[string]paho.MessageHandler)
+}
+
+func (s *stubDroppingPubSub) Deliver(topic string, payload string) bool {
+	s.mu.Lock()
+	cb, ok := s.subscriptions[topic]
+	s.mu.Unlock()
+
+	if ok {
+		cb(nil, &stubMessage{topic: topic, payload: []byte(payload)})
+	}
+	return ok
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+
+	paho5 "github.com/eclipse/paho.golang/paho"
+)
+
+type stubPubSub5 struct {
+	publishArgs       []*paho5.Publish
+	publishResponse   *paho5.PublishResponse
+	publishErr        error
+	subscribeArgs     []*paho5.Subscribe
+	subscribeResponse *paho5.Suback
+	subscribeErr      error
+	unsubscribeArgs   []*paho5.Unsubscribe
+	unsubscribeResp   *paho5.Unsuback
+	unsubscribeErr    error
+}
+
+func (s *stubPubSub5) Publish(_ context.Context, p *paho5.Publish) (*paho5.PublishResponse, error) {
+	s.publishArgs = append(s.publishArgs, p)
+	return s.publishResponse, s.publishErr
+}
+
+func (s *stubPubSub5) Subscribe(_ context.Context, sub *paho5.Subscribe) (*paho5.Suback, error) {
+	s.subscribeArgs = append(s.subscribeArgs, sub)
+	return s.subscribeResponse, s.subscribeErr
+}
+
+func (s *stubPubSub5) Unsubscribe(_ context.Context, u *paho5.Unsubscribe) (*paho5.Unsuback, error) {
+	s.unsubscribeArgs = append(s.unsubscribeArgs, u)
+	return s.unsubscribeResp, s.unsubscribeErr
+}
@@ -3,13 +3,19 @@
 
 package mqtt_test
 
-import paho "github.com/eclipse/paho.mqtt.golang"
+import (
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
 
 type stubPubSub struct {
 	publishArgs       []publishArgs
+	publishHandler    func(count int)
 	publishTokens     []paho.Token
 	subscribeArgs     []subscribeArgs
 	subscribeHandler  func(callback paho.MessageHandler)
+	subscribeMatching bool
 	subscribeTokens   []paho.Token
 	unsubscribeArgs   []unsubscribeArgs
 	unsubscribeTokens []paho.Token
@@ -30,6 +36,10 @@ func (s *stubPubSub) Publish(topic string, qos byte, retained bool, payload inte
 		payload:  payload,
 	})
 
+	if s.publishHandler != nil {
+		s.publishHandler(len(s.publishArgs))
+	}
+
 	count := len(s.publishArgs) - 1
 	if count < len(s.publishTokens) {
 		return s.publishTokens[count]
@@ -54,10 +64,21 @@ func (s *stubPubSub) Subscribe(topic string, qos byte, callback paho.MessageHand
 	})
 
 	if s.subscribeHandler != nil {
-		go s.subscribeHandler(callback)
+		cb := callback
+
+		// a broker only delivers the messages that match a subscription, to each subscription
+		if s.subscribeMatching {
+			cb = func(c paho.Client, m paho.Message) {
+				if MatchTopic(topic, m.Topic()) {
+					callback(c, m)
+				}
+			}
+		}
+
+		go s.subscribeHandler(cb)
 	}
 
-	count := len(s.subscribeArgs)
+	count := len(s.subscribeArgs) - 1
 	if count < len(s.subscribeTokens) {
 		return s.subscribeTokens[count]
 	}
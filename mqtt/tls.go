@@ -0,0 +1,78 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	c := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		ServerName:         config.ServerName,
+	}
+
+	switch config.MinVersion {
+	case "", "1.2":
+		c.MinVersion = tls.VersionTLS12
+	case "1.0":
+		c.MinVersion = tls.VersionTLS10
+	case "1.1":
+		c.MinVersion = tls.VersionTLS11
+	case "1.3":
+		c.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("mqtt tls min version must be one of 1.0, 1.1, 1.2, 1.3")
+	}
+
+	if config.CAFile != "" {
+		b, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read mqtt tls ca file: %w", err)
+		}
+
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in mqtt tls ca file %s", config.CAFile)
+		}
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("mqtt tls cert file and key file must be specified together")
+		}
+
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load mqtt tls client certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
+
+func TLSError(err error) error {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalid          x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		verification     *tls.CertificateVerificationError
+	)
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Errorf("unable to verify broker certificate, signed by an unknown authority (see --mqtt-tls-ca-file): %w", err)
+	case errors.As(err, &hostname):
+		return fmt.Errorf("unable to verify broker certificate, host name does not match (see --mqtt-tls-server-name): %w", err)
+	case errors.As(err, &invalid), errors.As(err, &verification):
+		return fmt.Errorf("unable to verify broker certificate: %w", err)
+	default:
+		return err
+	}
+}
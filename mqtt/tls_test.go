@@ -0,0 +1,204 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCertificate(t, dir, "ca", nil, nil)
+	_, _ = writeCertificate(t, dir, "client", ca, caKey)
+
+	if err := os.WriteFile(filepath.Join(dir, "empty.pem"), []byte("no certificates"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		config    TLSConfig
+		wantCAs   bool
+		wantCerts int
+		wantMin   uint16
+		wantErr   bool
+	}{
+		{
+			name:    "default",
+			config:  TLSConfig{},
+			wantMin: tls.VersionTLS12,
+		},
+		{
+			name: "all",
+			config: TLSConfig{
+				CAFile:     filepath.Join(dir, "ca.pem"),
+				CertFile:   filepath.Join(dir, "client.pem"),
+				KeyFile:    filepath.Join(dir, "client-key.pem"),
+				ServerName: "test-server-name",
+				MinVersion: "1.3",
+			},
+			wantCAs:   true,
+			wantCerts: 1,
+			wantMin:   tls.VersionTLS13,
+		},
+		{
+			name:    "missing ca file",
+			config:  TLSConfig{CAFile: filepath.Join(dir, "missing.pem")},
+			wantErr: true,
+		},
+		{
+			name:    "empty ca file",
+			config:  TLSConfig{CAFile: filepath.Join(dir, "empty.pem")},
+			wantErr: true,
+		},
+		{
+			name:    "cert without key",
+			config:  TLSConfig{CertFile: filepath.Join(dir, "client.pem")},
+			wantErr: true,
+		},
+		{
+			name: "mismatched key",
+			config: TLSConfig{
+				CertFile: filepath.Join(dir, "client.pem"),
+				KeyFile:  filepath.Join(dir, "ca-key.pem"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid min version",
+			config:  TLSConfig{MinVersion: "2.0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTLSConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if (got.RootCAs != nil) != tt.wantCAs {
+				t.Errorf("NewTLSConfig() RootCAs = %v, want %v", got.RootCAs != nil, tt.wantCAs)
+			}
+			if len(got.Certificates) != tt.wantCerts {
+				t.Errorf("NewTLSConfig() Certificates = %d, want %d", len(got.Certificates), tt.wantCerts)
+			}
+			if got.MinVersion != tt.wantMin {
+				t.Errorf("NewTLSConfig() MinVersion = %x, want %x", got.MinVersion, tt.wantMin)
+			}
+			if got.ServerName != tt.config.ServerName {
+				t.Errorf("NewTLSConfig() ServerName = %v, want %v", got.ServerName, tt.config.ServerName)
+			}
+		})
+	}
+}
+
+func TestNewMQTT_TLSVerification(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCertificate(t, dir, "ca", nil, nil)
+	_, _ = writeCertificate(t, dir, "server", ca, caKey)
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.(*tls.Conn).Handshake()
+			_ = c.Close()
+		}
+	}()
+
+	_, err = NewMQTT(context.Background(), Config{
+		Scheme:   "ssl",
+		Host:     "127.0.0.1",
+		Port:     l.Addr().(*net.TCPAddr).Port,
+		Username: "test-username",
+		Password: "test-password",
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown authority") {
+		t.Errorf("NewMQTT() error = %v, want unknown authority error", err)
+	}
+}
+
+func writeCertificate(t *testing.T, dir string, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: k}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, key
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+const (
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+)
+
+func (m *MQTT) WatchStatus(ctx context.Context, haCfg ha.Config, fn func(ctx context.Context) error) error {
+	topic := StatusTopic(haCfg)
+	fmt.Printf("Watching %s\n", topic)
+
+	// missing a birth message while disconnected would leave home assistant without configuration, so it is queued
+	in, err := m.Subscribe(ctx, topic, m.QoS)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.Unsubscribe(ctx, topic) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case msg := <-in:
+			// a retained birth message predates this process and has already been answered by the initial publish
+			if msg.Retained() || string(msg.Payload()) != StatusOnline {
+				continue
+			}
+
+			fmt.Println("Home Assistant Online")
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func StatusTopic(haCfg ha.Config) string {
+	return fmt.Sprintf("%s/status", haCfg.DiscoveryPrefix)
+}
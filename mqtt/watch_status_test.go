@@ -0,0 +1,107 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+)
+
+func TestMQTT_WatchStatus(t *testing.T) {
+	errStop := fmt.Errorf("stop")
+
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		haCfg ha.Config
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantCalls int
+		wantTopic string
+		wantErr   error
+	}{
+		{
+			name: "online",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:    "test-discovery-prefix/status",
+							payload:  []byte("online"),
+							retained: true,
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/status",
+							payload: []byte("offline"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-discovery-prefix/status",
+							payload: []byte("online"),
+						})
+					},
+					subscribeTokens:   []paho.Token{&stubToken{}},
+					unsubscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+			},
+			wantCalls: 1,
+			wantTopic: "test-discovery-prefix/status",
+			wantErr:   errStop,
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("subscribe error")},
+					},
+				},
+			},
+			args: args{
+				haCfg: ha.Config{DiscoveryPrefix: "test-discovery-prefix"},
+			},
+			wantTopic: "test-discovery-prefix/status",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+
+			calls := 0
+			err := m.WatchStatus(context.Background(), tt.args.haCfg, func(ctx context.Context) error {
+				calls++
+				return errStop
+			})
+			if err == nil {
+				t.Errorf("MQTT.WatchStatus() error = %v, want error", err)
+				return
+			}
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("MQTT.WatchStatus() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("MQTT.WatchStatus() calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			if got := tt.fields.Client.subscribeArgs[0].topic; got != tt.wantTopic {
+				t.Errorf("MQTT.WatchStatus() topic = %v, want %v", got, tt.wantTopic)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	"github.com/nebhale/teslamate-discovery/tm"
+)
+
+func (m *MQTT) WatchVehicles(ctx context.Context, tmCfg tm.Config, known map[string]ha.Device,
+	fn func(ctx context.Context, id string, dev ha.Device) error) error {
+
+	topic := fmt.Sprintf("%s/cars/+/+", tmCfg.Prefix)
+	fmt.Printf("Watching %s\n", topic)
+
+	in, err := m.Subscribe(ctx, topic, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.Unsubscribe(ctx, topic) }()
+
+	published := make(map[string]ha.Device, len(known))
+	for id, dev := range known {
+		published[id] = dev
+	}
+
+	skipped := make(map[string]ha.Device)
+	vehicles := make(map[string]*tm.Vehicle)
+	r := VehicleTopicRegexp(tmCfg)
+
+	// changes are debounced so that a burst of metadata updates results in a single publish
+	pending := make(map[string]time.Time)
+	due := make(chan string)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case msg := <-in:
+			s := r.FindStringSubmatch(msg.Topic())
+			if s == nil {
+				continue
+			}
+
+			v, ok := vehicles[s[1]]
+			if !ok {
+				v = &tm.Vehicle{}
+				vehicles[s[1]] = v
+			}
+
+			if !v.Set(s[2], string(msg.Payload())) || !v.Complete(tmCfg.RequiredMetadata) {
+				continue
+			}
+
+			id := s[1]
+			pending[id] = time.Now().Add(tmCfg.MetadataDebounce)
+			time.AfterFunc(tmCfg.MetadataDebounce, func() {
+				select {
+				case <-ctx.Done():
+				case due <- id:
+				}
+			})
+
+		case id := <-due:
+			if t, ok := pending[id]; !ok || time.Now().Before(t) {
+				continue
+			}
+			delete(pending, id)
+
+			dev := vehicles[id].Device(tmCfg, id)
+
+			prev, ok := published[id]
+			if ok && reflect.DeepEqual(prev, dev) {
+				continue
+			}
+
+			match, reason, err := tmCfg.MatchVehicle(id, dev.Name)
+			if err != nil {
+				return err
+			}
+			if !match {
+				if s, ok := skipped[id]; !ok || !reflect.DeepEqual(s, dev) {
+					fmt.Printf("Skipping %s (%s): %s\n", dev.Name, id, reason)
+					skipped[id] = dev
+				}
+				continue
+			}
+			delete(skipped, id)
+
+			if ok {
+				fmt.Printf("Updated Vehicle %s\n", id)
+			} else {
+				fmt.Printf("Discovered Vehicle %s\n", id)
+			}
+
+			if err := fn(ctx, id, dev); err != nil {
+				return err
+			}
+
+			published[id] = dev
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/mqtt"
+	"github.com/nebhale/teslamate-discovery/tm"
+)
+
+func TestMQTT_WatchVehicles(t *testing.T) {
+	type fields struct {
+		Client stubPubSub
+	}
+	type args struct {
+		tmCfg tm.Config
+		known map[string]ha.Device
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    map[string]ha.Device
+		wantErr bool
+	}{
+		{
+			name: "new and updated vehicles",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeHandler: func(cb paho.MessageHandler) {
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/display_name",
+							payload: []byte("test-display-name-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/model",
+							payload: []byte("test-model-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/1/version",
+							payload: []byte("test-version-1"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/4/display_name",
+							payload: []byte("test-display-name-4"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/4/model",
+							payload: []byte("test-model-4"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/4/version",
+							payload: []byte("test-version-4"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/display_name",
+							payload: []byte("test-display-name-2"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/3/model",
+							payload: []byte("test-model-3"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/model",
+							payload: []byte("test-model-2"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/trim_badging",
+							payload: []byte("test-trim-badging-2"),
+						})
+						cb(nil, &stubMessage{
+							topic:   "test-prefix/cars/2/version",
+							payload: []byte("test-version-2"),
+						})
+					},
+					subscribeTokens:   []paho.Token{&stubToken{}},
+					unsubscribeTokens: []paho.Token{&stubToken{}},
+				},
+			},
+			args: args{
+				tmCfg: tm.Config{
+					MetadataDebounce: 50 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: tm.DefaultRequiredMetadata,
+				},
+				known: map[string]ha.Device{
+					"1": {
+						Identifiers:     []string{"test-prefix/cars/1"},
+						Manufacturer:    "Tesla",
+						Model:           "Model test-model-1",
+						Name:            "test-display-name-1",
+						SoftwareVersion: "test-old-version-1",
+						SuggestedArea:   "Garage",
+					},
+					"4": {
+						Identifiers:     []string{"test-prefix/cars/4"},
+						Manufacturer:    "Tesla",
+						Model:           "Model test-model-4",
+						Name:            "test-display-name-4",
+						SoftwareVersion: "test-version-4",
+						SuggestedArea:   "Garage",
+					},
+				},
+			},
+			want: map[string]ha.Device{
+				"1": {
+					Identifiers:     []string{"test-prefix/cars/1"},
+					Manufacturer:    "Tesla",
+					Model:           "Model test-model-1",
+					Name:            "test-display-name-1",
+					SoftwareVersion: "test-version-1",
+					SuggestedArea:   "Garage",
+				},
+				"2": {
+					Identifiers:     []string{"test-prefix/cars/2"},
+					Manufacturer:    "Tesla",
+					Model:           "Model test-model-2 test-trim-badging-2",
+					Name:            "test-display-name-2",
+					SoftwareVersion: "test-version-2",
+					SuggestedArea:   "Garage",
+				},
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: stubPubSub{
+					subscribeTokens: []paho.Token{
+						&stubToken{err: fmt.Errorf("subscribe error")},
+					},
+				},
+			},
+			args: args{
+				tmCfg: tm.Config{
+					MetadataDebounce: 50 * time.Millisecond,
+					Prefix:           "test-prefix",
+					RequiredMetadata: tm.DefaultRequiredMetadata,
+				},
+			},
+			want:    map[string]ha.Device{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MQTT{
+				Client: &tt.fields.Client,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got := make(map[string]ha.Device)
+			err := m.WatchVehicles(ctx, tt.args.tmCfg, tt.args.known,
+				func(ctx context.Context, id string, dev ha.Device) error {
+					got[id] = dev
+					if len(got) == len(tt.want) {
+						cancel()
+					}
+					return nil
+				})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MQTT.WatchVehicles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got := tt.fields.Client.subscribeArgs[0].topic; got != "test-prefix/cars/+/+" {
+				t.Errorf("MQTT.WatchVehicles() topic = %v, want %v", got, "test-prefix/cars/+/+")
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MQTT.WatchVehicles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+func IsWebSocket(scheme string) bool {
+	return scheme == "ws" || scheme == "wss"
+}
+
+func NewWebSocketHeaders(config WebSocketConfig) (http.Header, error) {
+	h := http.Header{}
+
+	for _, s := range config.Headers {
+		name, value, ok := strings.Cut(s, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("mqtt websocket header %q must be in the form name: value", s)
+		}
+
+		h.Add(textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(value))
+	}
+
+	return h, nil
+}
+
+func NewWebSocketOptions(config WebSocketConfig) (*paho.WebsocketOptions, error) {
+	o := &paho.WebsocketOptions{}
+
+	// an empty proxy leaves paho to honor HTTP_PROXY, HTTPS_PROXY, and NO_PROXY
+	if config.Proxy != "" {
+		u, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse mqtt websocket proxy %s", RedactURL(config.Proxy))
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("mqtt websocket proxy %s must be an absolute url", RedactURL(config.Proxy))
+		}
+
+		o.Proxy = http.ProxyURL(u)
+	}
+
+	return o, nil
+}
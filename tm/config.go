@@ -3,14 +3,53 @@
 
 package tm
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
-	DefaultPrefix = "teslamate"
+	DefaultExpectedVehicles = 0
+	DefaultIdleTimeout      = 250 * time.Millisecond
+	DefaultMetadataDebounce = 5 * time.Second
+	DefaultPrefix           = "teslamate"
+	DefaultTimeout          = 10 * time.Second
 )
 
+var DefaultRequiredMetadata = []string{"display_name", "model", "version"}
+
 var DefaultConfig = Config{
-	Prefix: DefaultPrefix,
+	ExpectedVehicles: DefaultExpectedVehicles,
+	IdleTimeout:      DefaultIdleTimeout,
+	MetadataDebounce: DefaultMetadataDebounce,
+	Prefix:           DefaultPrefix,
+	RequiredMetadata: DefaultRequiredMetadata,
+	Timeout:          DefaultTimeout,
 }
 
 type Config struct {
-	Prefix string `mapstructure:"prefix"`
+	ExcludeVehicles  []string      `mapstructure:"exclude_vehicles"`
+	ExpectedVehicles int           `mapstructure:"expected_vehicles"`
+	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`
+	MetadataDebounce time.Duration `mapstructure:"metadata_debounce"`
+	Prefix           string        `mapstructure:"prefix"`
+	RequiredMetadata []string      `mapstructure:"required_metadata"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	Vehicles         []string      `mapstructure:"vehicles"`
+}
+
+func (c Config) Validate() error {
+	for _, m := range c.RequiredMetadata {
+		if _, ok := (Vehicle{}).Get(m); !ok {
+			return fmt.Errorf("required metadata must be one of display_name, model, trim_badging, version")
+		}
+	}
+
+	for _, p := range append(append([]string{}, c.Vehicles...), c.ExcludeVehicles...) {
+		if _, err := MatchVehiclePattern(p, "", ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,49 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package tm_test
+
+import (
+	"testing"
+
+	. "github.com/nebhale/teslamate-discovery/tm"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			config: DefaultConfig,
+		},
+		{
+			name:   "all metadata",
+			config: Config{RequiredMetadata: []string{"display_name", "model", "trim_badging", "version"}},
+		},
+		{
+			name:    "invalid vehicle pattern",
+			config:  Config{Vehicles: []string{"/(/"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid exclude vehicle pattern",
+			config:  Config{ExcludeVehicles: []string{"["}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown metadata",
+			config:  Config{RequiredMetadata: []string{"speed"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
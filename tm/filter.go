@@ -0,0 +1,99 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package tm
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+func (c Config) MatchVehicle(id string, name string) (bool, string, error) {
+	if len(c.Vehicles) > 0 {
+		included := false
+
+		for _, p := range c.Vehicles {
+			ok, err := MatchVehiclePattern(p, id, name)
+			if err != nil {
+				return false, "", err
+			}
+			if ok {
+				included = true
+				break
+			}
+		}
+
+		if !included {
+			return false, fmt.Sprintf("not matched by vehicles %q", c.Vehicles), nil
+		}
+	}
+
+	for _, p := range c.ExcludeVehicles {
+		ok, err := MatchVehiclePattern(p, id, name)
+		if err != nil {
+			return false, "", err
+		}
+		if ok {
+			return false, fmt.Sprintf("matched by exclude vehicles %q", p), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// MatchVehicleId matches a vehicle whose name is not yet known by its id alone.  A vehicle that only its name could
+// include is pending until the name arrives, while one that only its name could exclude is matched in the meantime.
+func (c Config) MatchVehicleId(id string) (match bool, pending bool, err error) {
+	// the id stands in for the missing name, so that only the id is matched
+	for _, p := range c.ExcludeVehicles {
+		ok, err := MatchVehiclePattern(p, id, id)
+		if err != nil {
+			return false, false, err
+		}
+		if ok {
+			return false, false, nil
+		}
+	}
+
+	if len(c.Vehicles) == 0 {
+		return true, false, nil
+	}
+
+	for _, p := range c.Vehicles {
+		ok, err := MatchVehiclePattern(p, id, id)
+		if err != nil {
+			return false, false, err
+		}
+		if ok {
+			return true, false, nil
+		}
+	}
+
+	return false, true, nil
+}
+
+func MatchVehiclePattern(pattern string, id string, name string) (bool, error) {
+	// patterns surrounded by slashes are regular expressions, all others are globs
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		r, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, fmt.Errorf("invalid vehicle pattern %q: %w", pattern, err)
+		}
+
+		return r.MatchString(id) || r.MatchString(name), nil
+	}
+
+	for _, s := range []string{id, name} {
+		ok, err := path.Match(pattern, s)
+		if err != nil {
+			return false, fmt.Errorf("invalid vehicle pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
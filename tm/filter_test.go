@@ -0,0 +1,151 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package tm_test
+
+import (
+	"testing"
+
+	. "github.com/nebhale/teslamate-discovery/tm"
+)
+
+func TestConfig_MatchVehicle(t *testing.T) {
+	type args struct {
+		id   string
+		name string
+	}
+	tests := []struct {
+		name    string
+		config  Config
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "no filters",
+			config: Config{},
+			args:   args{id: "1", name: "test-name"},
+			want:   true,
+		},
+		{
+			name:   "included by id",
+			config: Config{Vehicles: []string{"2", "1"}},
+			args:   args{id: "1", name: "test-name"},
+			want:   true,
+		},
+		{
+			name:   "included by name glob",
+			config: Config{Vehicles: []string{"test-*"}},
+			args:   args{id: "1", name: "test-name"},
+			want:   true,
+		},
+		{
+			name:   "included by name regexp",
+			config: Config{Vehicles: []string{"/^TEST|name$/"}},
+			args:   args{id: "1", name: "test-name"},
+			want:   true,
+		},
+		{
+			name:   "not included",
+			config: Config{Vehicles: []string{"2", "other-*"}},
+			args:   args{id: "1", name: "test-name"},
+		},
+		{
+			name:   "excluded by id",
+			config: Config{ExcludeVehicles: []string{"1"}},
+			args:   args{id: "1", name: "test-name"},
+		},
+		{
+			name:   "included and excluded",
+			config: Config{Vehicles: []string{"*"}, ExcludeVehicles: []string{"/name/"}},
+			args:   args{id: "1", name: "test-name"},
+		},
+		{
+			name:    "invalid glob",
+			config:  Config{Vehicles: []string{"["}},
+			args:    args{id: "1", name: "test-name"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regexp",
+			config:  Config{ExcludeVehicles: []string{"/(/"}},
+			args:    args{id: "1", name: "test-name"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason, err := tt.config.MatchVehicle(tt.args.id, tt.args.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.MatchVehicle() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("Config.MatchVehicle() = %v, want %v", got, tt.want)
+			}
+			if (reason == "") != tt.want {
+				t.Errorf("Config.MatchVehicle() reason = %q", reason)
+			}
+		})
+	}
+}
+
+func TestConfig_MatchVehicleId(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		want        bool
+		wantPending bool
+		wantErr     bool
+	}{
+		{
+			name:   "no filters",
+			config: Config{},
+			want:   true,
+		},
+		{
+			name:   "included by id",
+			config: Config{Vehicles: []string{"1"}},
+			want:   true,
+		},
+		{
+			name:        "included by name",
+			config:      Config{Vehicles: []string{"test-*"}},
+			wantPending: true,
+		},
+		{
+			name:   "excluded by id",
+			config: Config{ExcludeVehicles: []string{"1"}},
+		},
+		{
+			name:   "excluded by name",
+			config: Config{ExcludeVehicles: []string{"test-*"}},
+			want:   true,
+		},
+		{
+			name:    "invalid pattern",
+			config:  Config{Vehicles: []string{"["}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pending, err := tt.config.MatchVehicleId("1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.MatchVehicleId() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got != tt.want || pending != tt.wantPending {
+				t.Errorf("Config.MatchVehicleId() = %v, %v, want %v, %v", got, pending, tt.want, tt.wantPending)
+			}
+		})
+	}
+}
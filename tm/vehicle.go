@@ -0,0 +1,89 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package tm
+
+import (
+	"fmt"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+)
+
+type Vehicle struct {
+	DisplayName string `mapstructure:"display_name"`
+	Model       string `mapstructure:"model"`
+	TrimBadging string `mapstructure:"trim_badging"`
+	Version     string `mapstructure:"version"`
+}
+
+func (v Vehicle) Complete(required []string) bool {
+	return len(v.Missing(required)) == 0
+}
+
+func (v Vehicle) Device(tmCfg Config, id string) ha.Device {
+	dev := ha.Device{
+		Identifiers:     []string{fmt.Sprintf("%s/cars/%s", tmCfg.Prefix, id)},
+		Manufacturer:    "Tesla",
+		Name:            v.DisplayName,
+		SoftwareVersion: v.Version,
+		SuggestedArea:   "Garage",
+	}
+
+	if dev.Name == "" {
+		dev.Name = "Tesla"
+	}
+
+	if v.Model != "" {
+		dev.Model = fmt.Sprintf("Model %s", v.Model)
+	}
+	if v.TrimBadging != "" {
+		dev.Model = fmt.Sprintf("%s %s", dev.Model, v.TrimBadging)
+	}
+
+	return dev
+}
+
+func (v Vehicle) Get(field string) (string, bool) {
+	if p := v.field(field); p != nil {
+		return *p, true
+	}
+
+	return "", false
+}
+
+func (v Vehicle) Missing(required []string) []string {
+	var missing []string
+
+	for _, r := range required {
+		if s, _ := v.Get(r); s == "" {
+			missing = append(missing, r)
+		}
+	}
+
+	return missing
+}
+
+func (v *Vehicle) Set(field string, value string) bool {
+	p := v.field(field)
+	if p == nil || *p == value {
+		return false
+	}
+
+	*p = value
+	return true
+}
+
+func (v *Vehicle) field(field string) *string {
+	switch field {
+	case "display_name":
+		return &v.DisplayName
+	case "model":
+		return &v.Model
+	case "trim_badging":
+		return &v.TrimBadging
+	case "version":
+		return &v.Version
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 Ben Hale
+// SPDX-License-Identifier: Apache-2.0
+
+package tm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nebhale/teslamate-discovery/ha"
+	. "github.com/nebhale/teslamate-discovery/tm"
+)
+
+func TestVehicle_Complete(t *testing.T) {
+	tests := []struct {
+		name    string
+		vehicle Vehicle
+		want    bool
+	}{
+		{
+			name: "complete",
+			vehicle: Vehicle{
+				DisplayName: "test-display-name",
+				Model:       "test-model",
+				Version:     "test-version",
+			},
+			want: true,
+		},
+		{
+			name: "no display name",
+			vehicle: Vehicle{
+				Model:   "test-model",
+				Version: "test-version",
+			},
+		},
+		{
+			name: "no model",
+			vehicle: Vehicle{
+				DisplayName: "test-display-name",
+				Version:     "test-version",
+			},
+		},
+		{
+			name: "no version",
+			vehicle: Vehicle{
+				DisplayName: "test-display-name",
+				Model:       "test-model",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.Complete(DefaultRequiredMetadata); got != tt.want {
+				t.Errorf("Vehicle.Complete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicle_Missing(t *testing.T) {
+	tests := []struct {
+		name     string
+		vehicle  Vehicle
+		required []string
+		want     []string
+	}{
+		{
+			name:     "complete",
+			vehicle:  Vehicle{Model: "test-model", TrimBadging: "test-trim-badging"},
+			required: []string{"model", "trim_badging"},
+		},
+		{
+			name:     "missing",
+			vehicle:  Vehicle{Model: "test-model"},
+			required: []string{"display_name", "model", "version"},
+			want:     []string{"display_name", "version"},
+		},
+		{
+			name:    "none required",
+			vehicle: Vehicle{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.Missing(tt.required); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Vehicle.Missing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicle_Device(t *testing.T) {
+	type args struct {
+		tmCfg Config
+		id    string
+	}
+	tests := []struct {
+		name    string
+		vehicle Vehicle
+		args    args
+		want    ha.Device
+	}{
+		{
+			name: "complete",
+			vehicle: Vehicle{
+				DisplayName: "test-display-name",
+				Model:       "test-model",
+				TrimBadging: "test-trim-badging",
+				Version:     "test-version",
+			},
+			args: args{
+				tmCfg: Config{Prefix: "test-prefix"},
+				id:    "1",
+			},
+			want: ha.Device{
+				Identifiers:     []string{"test-prefix/cars/1"},
+				Manufacturer:    "Tesla",
+				Model:           "Model test-model test-trim-badging",
+				Name:            "test-display-name",
+				SoftwareVersion: "test-version",
+				SuggestedArea:   "Garage",
+			},
+		},
+		{
+			name: "empty",
+			args: args{
+				tmCfg: Config{Prefix: "test-prefix"},
+				id:    "1",
+			},
+			want: ha.Device{
+				Identifiers:   []string{"test-prefix/cars/1"},
+				Manufacturer:  "Tesla",
+				Name:          "Tesla",
+				SuggestedArea: "Garage",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.Device(tt.args.tmCfg, tt.args.id); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Vehicle.Device() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicle_Set(t *testing.T) {
+	type args struct {
+		field string
+		value string
+	}
+	tests := []struct {
+		name    string
+		vehicle Vehicle
+		args    args
+		want    bool
+		wantV   Vehicle
+	}{
+		{
+			name:  "display name",
+			args:  args{field: "display_name", value: "test-display-name"},
+			want:  true,
+			wantV: Vehicle{DisplayName: "test-display-name"},
+		},
+		{
+			name:  "model",
+			args:  args{field: "model", value: "test-model"},
+			want:  true,
+			wantV: Vehicle{Model: "test-model"},
+		},
+		{
+			name:  "trim badging",
+			args:  args{field: "trim_badging", value: "test-trim-badging"},
+			want:  true,
+			wantV: Vehicle{TrimBadging: "test-trim-badging"},
+		},
+		{
+			name:  "version",
+			args:  args{field: "version", value: "test-version"},
+			want:  true,
+			wantV: Vehicle{Version: "test-version"},
+		},
+		{
+			name:    "unchanged",
+			vehicle: Vehicle{Version: "test-version"},
+			args:    args{field: "version", value: "test-version"},
+			wantV:   Vehicle{Version: "test-version"},
+		},
+		{
+			name: "unknown",
+			args: args{field: "speed", value: "42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.vehicle
+			if got := v.Set(tt.args.field, tt.args.value); got != tt.want {
+				t.Errorf("Vehicle.Set() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(v, tt.wantV) {
+				t.Errorf("Vehicle.Set() vehicle = %v, want %v", v, tt.wantV)
+			}
+		})
+	}
+}